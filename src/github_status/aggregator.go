@@ -0,0 +1,281 @@
+package main
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github_status/crawler"
+	"github_status/stats"
+	"github_status/storage"
+)
+
+// Snapshot is the state of the crawl at one moment. Snapshots are shared
+// between readers and must not be modified.
+type Snapshot struct {
+	Languages          map[string]int `json:"languages"`
+	LanguageRepos      map[string]int `json:"language_repos"`
+	Repos              int            `json:"repos"`
+	Filtered           map[string]int `json:"filtered"`
+	Pages              int            `json:"pages"`
+	TotalPages         int            `json:"total_pages"`
+	RateLimitRemaining int            `json:"rate_limit_remaining"`
+	RateLimitReset     time.Time      `json:"rate_limit_reset"`
+	StartedAt          time.Time      `json:"started_at"`
+	UpdatedAt          time.Time      `json:"updated_at"`
+	// Weighted are the language totals of this crawl under every weighting.
+	Weighted map[stats.Weighting]map[string]float64 `json:"weighted"`
+	// Sample is set when the crawl draws random pages with -sample.
+	Sample *crawler.Estimate `json:"sample,omitempty"`
+}
+
+// Shares returns the language totals under w in whole units for drawing:
+// bytes for stats.Raw, including any seeded from an earlier run, and
+// thousandths of a weight otherwise.
+func (s *Snapshot) Shares(w stats.Weighting) map[string]int {
+	if w == stats.Raw || w == "" {
+		return s.Languages
+	}
+	shares := make(map[string]int, len(s.Weighted[w]))
+	for lang, weight := range s.Weighted[w] {
+		shares[lang] = int(math.Round(weight * 1000))
+	}
+	return shares
+}
+
+// Aggregator owns the crawl totals. The crawl feeds it Data and any number of
+// readers take Snapshots of it concurrently.
+type Aggregator struct {
+	// Normalize, when set, maps the languages of every repo before they are
+	// counted, for instance to leave out markup or group dialects.
+	Normalize func(map[string]int) map[string]int
+	// WeightCap is the most bytes a repo counts for under stats.Capped.
+	WeightCap int
+
+	mutex       sync.Mutex
+	state       Snapshot
+	snapshot    *Snapshot
+	subscribers map[chan *Snapshot]bool
+
+	collector *stats.Collector
+	// summaryMutex lets one caller of Stats at a time bootstrap a summary,
+	// which happens without holding mutex so updates go on meanwhile.
+	summaryMutex sync.Mutex
+	summary      *stats.Summary
+	summarizedAt time.Time
+	summaryStale bool
+}
+
+// StatsInterval is how often Stats recomputes the distributions of a running
+// crawl, which is too costly to do for every update.
+const StatsInterval = time.Second
+
+func NewAggregator() *Aggregator {
+	now := time.Now()
+	return &Aggregator{
+		state: Snapshot{
+			Languages:     make(map[string]int),
+			LanguageRepos: make(map[string]int),
+			Filtered:      make(map[string]int),
+			Weighted:      make(map[stats.Weighting]map[string]float64),
+			StartedAt:     now,
+			UpdatedAt:     now,
+		},
+		collector: stats.NewCollector(now.UnixNano()),
+		WeightCap: stats.DefaultCap,
+	}
+}
+
+// Seed starts the totals from an earlier run, such as the language totals of
+// the store or a checkpoint. When Normalize merges languages, the repos of
+// each are added up, so a repo using several of them is counted more than
+// once.
+func (a *Aggregator) Seed(languages map[string]int, repos map[string]int) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	languages, repos = a.normalize(languages), a.normalize(repos)
+	for lang, bytes := range languages {
+		a.state.Languages[lang] += bytes
+	}
+	for lang, count := range repos {
+		a.state.LanguageRepos[lang] += count
+	}
+	a.snapshot = nil
+	a.publish()
+}
+
+// Add applies one update from the crawl. A repo that was counted before is
+// taken out of the totals first, so re-crawling it replaces its languages.
+func (a *Aggregator) Add(data Data) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if data.Previous != nil {
+		a.count(data.Previous, -1)
+	}
+	if data.Repo != nil {
+		a.count(data.Repo, 1)
+	}
+
+	for reason, count := range data.Filtered {
+		a.state.Filtered[reason] += count
+	}
+	if data.Pages > 0 {
+		a.state.Pages = data.Pages
+		a.state.TotalPages = data.TotalPages
+	}
+	if data.Sample != nil {
+		a.state.Sample = data.Sample
+	}
+	if !data.Reset.IsZero() {
+		a.state.RateLimitRemaining = data.Limit
+		a.state.RateLimitReset = data.Reset
+	}
+	a.state.UpdatedAt = time.Now()
+	a.snapshot = nil
+	a.publish()
+}
+
+func (a *Aggregator) normalize(languages map[string]int) map[string]int {
+	if a.Normalize == nil || languages == nil {
+		return languages
+	}
+	return a.Normalize(languages)
+}
+
+func (a *Aggregator) count(repo *storage.RepoLanguages, sign int) {
+	languages := a.normalize(repo.Languages)
+	if sign > 0 {
+		a.collector.Add(repo.FullName, languages)
+	} else {
+		a.collector.Remove(repo.FullName, languages)
+	}
+	a.summaryStale = true
+
+	for _, w := range stats.Weightings {
+		totals := a.state.Weighted[w]
+		if totals == nil {
+			totals = make(map[string]float64)
+			a.state.Weighted[w] = totals
+		}
+		for lang, weight := range stats.Weigh(w, languages, a.WeightCap) {
+			totals[lang] += float64(sign) * weight
+		}
+	}
+
+	a.state.Repos += sign
+	for lang, bytes := range languages {
+		a.state.Languages[lang] += sign * bytes
+		if bytes > 0 {
+			a.state.LanguageRepos[lang] += sign
+		}
+		if a.state.Languages[lang] == 0 && a.state.LanguageRepos[lang] == 0 {
+			delete(a.state.Languages, lang)
+			delete(a.state.LanguageRepos, lang)
+			for _, totals := range a.state.Weighted {
+				delete(totals, lang)
+			}
+		}
+	}
+}
+
+// Snapshot returns the current state. Readers between two updates share the
+// same snapshot.
+func (a *Aggregator) Snapshot() *Snapshot {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	return a.current()
+}
+
+// Stats describes the distribution of languages over the repos of this
+// crawl; repos counted by an earlier run through Seed are not part of it. It
+// is recomputed at most every StatsInterval.
+func (a *Aggregator) Stats() stats.Summary {
+	a.summaryMutex.Lock()
+	defer a.summaryMutex.Unlock()
+
+	a.mutex.Lock()
+	if a.summary != nil && !(a.summaryStale && time.Since(a.summarizedAt) >= StatsInterval) {
+		summary := *a.summary
+		a.mutex.Unlock()
+		return summary
+	}
+	snapshot := a.collector.Snapshot()
+	a.summaryStale = false
+	a.mutex.Unlock()
+
+	summary := snapshot.Summary()
+	a.mutex.Lock()
+	a.summary, a.summarizedAt = &summary, time.Now()
+	a.mutex.Unlock()
+	return summary
+}
+
+// Subscribe returns a channel that receives a snapshot after every update.
+// A slow subscriber only misses intermediate snapshots, never the latest.
+// Call cancel to stop receiving.
+func (a *Aggregator) Subscribe() (updates <-chan *Snapshot, cancel func()) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	ch := make(chan *Snapshot, 1)
+	if a.subscribers == nil {
+		a.subscribers = make(map[chan *Snapshot]bool)
+	}
+	a.subscribers[ch] = true
+	return ch, func() {
+		a.mutex.Lock()
+		defer a.mutex.Unlock()
+
+		if a.subscribers[ch] {
+			delete(a.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// publish hands the current snapshot to every subscriber, replacing one they
+// have not picked up yet. It is only called with the mutex held, so nothing
+// else sends on the channels in between.
+func (a *Aggregator) publish() {
+	if len(a.subscribers) == 0 {
+		return
+	}
+	snapshot := a.current()
+	for ch := range a.subscribers {
+		select {
+		case <-ch:
+		default:
+		}
+		ch <- snapshot
+	}
+}
+
+func (a *Aggregator) current() *Snapshot {
+	if a.snapshot == nil {
+		snapshot := a.state
+		snapshot.Languages = copyCounts(a.state.Languages)
+		snapshot.LanguageRepos = copyCounts(a.state.LanguageRepos)
+		snapshot.Filtered = copyCounts(a.state.Filtered)
+		snapshot.Weighted = make(map[stats.Weighting]map[string]float64, len(a.state.Weighted))
+		for w, totals := range a.state.Weighted {
+			weights := make(map[string]float64, len(totals))
+			for lang, weight := range totals {
+				weights[lang] = weight
+			}
+			snapshot.Weighted[w] = weights
+		}
+		a.snapshot = &snapshot
+	}
+	return a.snapshot
+}
+
+func copyCounts(counts map[string]int) map[string]int {
+	copied := make(map[string]int, len(counts))
+	for key, value := range counts {
+		copied[key] = value
+	}
+	return copied
+}
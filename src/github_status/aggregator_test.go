@@ -0,0 +1,147 @@
+package main
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github_status/stats"
+	"github_status/storage"
+)
+
+func repo(name string, languages map[string]int) *storage.RepoLanguages {
+	return &storage.RepoLanguages{FullName: name, Languages: languages}
+}
+
+func TestAggregator_Add_totals_bytes_and_repos_per_language(t *testing.T) {
+	aggregator := NewAggregator()
+
+	aggregator.Add(Data{Repo: repo("a/a", map[string]int{"Go": 100, "C": 10})})
+	aggregator.Add(Data{Repo: repo("b/b", map[string]int{"Go": 50})})
+
+	snapshot := aggregator.Snapshot()
+	assert.Equal(t, map[string]int{"Go": 150, "C": 10}, snapshot.Languages)
+	assert.Equal(t, map[string]int{"Go": 2, "C": 1}, snapshot.LanguageRepos)
+	assert.Equal(t, 2, snapshot.Repos)
+}
+
+func TestAggregator_Add_replaces_a_repo_counted_before(t *testing.T) {
+	aggregator := NewAggregator()
+	aggregator.Add(Data{Repo: repo("a/a", map[string]int{"Go": 100, "C": 10})})
+
+	aggregator.Add(Data{Repo: repo("a/a", map[string]int{"Go": 120}), Previous: repo("a/a", map[string]int{"Go": 100, "C": 10})})
+
+	snapshot := aggregator.Snapshot()
+	assert.Equal(t, map[string]int{"Go": 120}, snapshot.Languages)
+	assert.Equal(t, map[string]int{"Go": 1}, snapshot.LanguageRepos)
+	assert.Equal(t, 1, snapshot.Repos)
+}
+
+func TestAggregator_Add_weighs_repos_under_every_weighting(t *testing.T) {
+	aggregator := NewAggregator()
+	aggregator.WeightCap = 100
+	aggregator.Add(Data{Repo: repo("a/huge", map[string]int{"C": 1000000})})
+	aggregator.Add(Data{Repo: repo("a/a", map[string]int{"Go": 30, "C": 10})})
+	aggregator.Add(Data{Repo: repo("a/b", map[string]int{"Rust": 50})})
+	aggregator.Add(Data{Repo: repo("a/b", map[string]int{"Go": 50}), Previous: repo("a/b", map[string]int{"Rust": 50})})
+
+	weighted := aggregator.Snapshot().Weighted
+	assert.Equal(t, map[string]float64{"C": 1000010, "Go": 80}, weighted[stats.Raw])
+	assert.Equal(t, map[string]float64{"C": 1.25, "Go": 1.75}, weighted[stats.Vote])
+	assert.Equal(t, map[string]float64{"C": 1, "Go": 2}, weighted[stats.Primary])
+	assert.Equal(t, map[string]float64{"C": 110, "Go": 80}, weighted[stats.Capped])
+	assert.Equal(t, map[string]int{"C": 1250, "Go": 1750}, aggregator.Snapshot().Shares(stats.Vote))
+}
+
+func TestAggregator_Add_tracks_progress_and_rate_limit(t *testing.T) {
+	aggregator := NewAggregator()
+	reset := time.Now().Add(time.Hour)
+
+	aggregator.Add(Data{Pages: 3, TotalPages: 10, Limit: 42, Reset: reset, Filtered: map[string]int{"fork": 2}})
+	aggregator.Add(Data{Filtered: map[string]int{"fork": 1}})
+
+	snapshot := aggregator.Snapshot()
+	assert.Equal(t, 3, snapshot.Pages)
+	assert.Equal(t, 10, snapshot.TotalPages)
+	assert.Equal(t, 42, snapshot.RateLimitRemaining)
+	assert.Equal(t, reset, snapshot.RateLimitReset)
+	assert.Equal(t, map[string]int{"fork": 3}, snapshot.Filtered)
+}
+
+func TestAggregator_Snapshot_is_not_changed_by_later_updates(t *testing.T) {
+	aggregator := NewAggregator()
+	aggregator.Seed(map[string]int{"Go": 10}, map[string]int{"Go": 1})
+	before := aggregator.Snapshot()
+
+	aggregator.Add(Data{Repo: repo("a/a", map[string]int{"Go": 5})})
+
+	assert.Equal(t, 10, before.Languages["Go"])
+	assert.Equal(t, 15, aggregator.Snapshot().Languages["Go"])
+}
+
+func TestAggregator_can_be_read_while_it_is_updated(t *testing.T) {
+	aggregator := NewAggregator()
+	var wg sync.WaitGroup
+	for reader := 0; reader < 4; reader++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				snapshot := aggregator.Snapshot()
+				sum := 0
+				for _, bytes := range snapshot.Languages {
+					sum += bytes
+				}
+				if sum != snapshot.Repos {
+					t.Errorf("inconsistent snapshot: %d bytes for %d repos", sum, snapshot.Repos)
+				}
+			}
+		}()
+	}
+	for i := 0; i < 200; i++ {
+		aggregator.Add(Data{Repo: repo("a/a", map[string]int{"Go": 1})})
+	}
+	wg.Wait()
+
+	assert.Equal(t, 200, aggregator.Snapshot().Repos)
+}
+
+func TestAggregator_Subscribe_receives_the_latest_snapshot(t *testing.T) {
+	aggregator := NewAggregator()
+	updates, cancel := aggregator.Subscribe()
+
+	aggregator.Add(Data{Repo: repo("a/a", map[string]int{"Go": 1})})
+	aggregator.Add(Data{Repo: repo("b/b", map[string]int{"Go": 1})})
+
+	assert.Equal(t, 2, (<-updates).Repos)
+	cancel()
+	_, open := <-updates
+	assert.False(t, open)
+}
+
+func TestAggregator_Normalize_is_applied_to_every_repo(t *testing.T) {
+	aggregator := NewAggregator()
+	aggregator.Normalize = func(languages map[string]int) map[string]int {
+		return map[string]int{"All": languages["Go"] + languages["C"]}
+	}
+
+	aggregator.Add(Data{Repo: repo("a/a", map[string]int{"Go": 100, "C": 10})})
+
+	assert.Equal(t, map[string]int{"All": 110}, aggregator.Snapshot().Languages)
+	assert.Equal(t, map[string]int{"All": 1}, aggregator.Snapshot().LanguageRepos)
+}
+
+func TestAggregator_Stats_describes_the_repos_of_this_crawl(t *testing.T) {
+	aggregator := NewAggregator()
+	aggregator.Seed(map[string]int{"C": 1000}, map[string]int{"C": 10})
+	aggregator.Add(Data{Repo: repo("a/a", map[string]int{"Go": 100})})
+	aggregator.Add(Data{Repo: repo("b/b", map[string]int{"Go": 300, "C": 100})})
+
+	summary := aggregator.Stats()
+
+	assert.Equal(t, 2, summary.Repos)
+	assert.Equal(t, int64(400), summary.Languages["Go"].Bytes)
+	assert.Equal(t, 200.0, summary.Languages["Go"].Median)
+	assert.Equal(t, 1, summary.Languages["C"].Repos)
+}
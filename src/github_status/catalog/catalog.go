@@ -0,0 +1,169 @@
+// Package catalog classifies the languages GitHub reports, following the
+// types, aliases and groups of github/linguist, so statistics can leave out
+// markup and data or count a dialect such as TSX as its parent language.
+package catalog
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+type Type string
+
+const (
+	Programming Type = "programming"
+	Markup      Type = "markup"
+	Data        Type = "data"
+	Prose       Type = "prose"
+)
+
+var Types = []Type{Programming, Markup, Data, Prose}
+
+type Language struct {
+	Name    string   `json:"name"`
+	Type    Type     `json:"type"`
+	Aliases []string `json:"aliases,omitempty"`
+	// Group is the language this one is counted as when grouping.
+	Group string `json:"group,omitempty"`
+}
+
+type Catalog struct {
+	languages []Language
+	lookup    map[string]int
+}
+
+//go:embed languages.json
+var embedded []byte
+
+var (
+	defaultOnce    sync.Once
+	defaultCatalog *Catalog
+)
+
+// Default is the catalog embedded in the binary.
+func Default() *Catalog {
+	defaultOnce.Do(func() {
+		catalog, err := Parse(embedded)
+		if err != nil {
+			panic(err)
+		}
+		defaultCatalog = catalog
+	})
+	return defaultCatalog
+}
+
+// Parse reads a catalog from a JSON array of languages.
+func Parse(data []byte) (*Catalog, error) {
+	c := &Catalog{lookup: make(map[string]int)}
+	if err := json.Unmarshal(data, &c.languages); err != nil {
+		return nil, err
+	}
+	for i, language := range c.languages {
+		for _, name := range append([]string{language.Name}, language.Aliases...) {
+			key := strings.ToLower(name)
+			if _, ok := c.lookup[key]; ok {
+				return nil, fmt.Errorf("catalog: %q is listed twice", name)
+			}
+			c.lookup[key] = i
+		}
+	}
+	for _, language := range c.languages {
+		if _, ok := c.Lookup(language.Group); language.Group != "" && !ok {
+			return nil, fmt.Errorf("catalog: %s is in unknown group %q", language.Name, language.Group)
+		}
+	}
+	return c, nil
+}
+
+// Languages returns every language in the catalog.
+func (c *Catalog) Languages() []Language {
+	return append([]Language(nil), c.languages...)
+}
+
+// Lookup finds a language by its name or an alias, ignoring case.
+func (c *Catalog) Lookup(name string) (Language, bool) {
+	i, ok := c.lookup[strings.ToLower(strings.TrimSpace(name))]
+	if !ok {
+		return Language{}, false
+	}
+	return c.languages[i], true
+}
+
+// Normalize returns the canonical name of a language, or name itself when the
+// catalog does not know it.
+func (c *Catalog) Normalize(name string) string {
+	if language, ok := c.Lookup(name); ok {
+		return language.Name
+	}
+	return name
+}
+
+// Mode selects how languages are aggregated.
+type Mode struct {
+	// Types to count, all of them when empty. Languages missing from the
+	// catalog are only counted when every type is.
+	Types []Type
+	// Group counts languages as the language of their group.
+	Group bool
+}
+
+func (m Mode) includes(t Type) bool {
+	if len(m.Types) == 0 {
+		return true
+	}
+	for _, included := range m.Types {
+		if included == t {
+			return true
+		}
+	}
+	return false
+}
+
+// Apply returns the languages that mode counts, renamed to their canonical or
+// group name, with the bytes of languages that end up under the same name
+// added up.
+func (c *Catalog) Apply(mode Mode, languages map[string]int) map[string]int {
+	applied := make(map[string]int, len(languages))
+	for name, bytes := range languages {
+		language, ok := c.Lookup(name)
+		if !ok {
+			if len(mode.Types) == 0 {
+				applied[name] += bytes
+			}
+			continue
+		}
+		if !mode.includes(language.Type) {
+			continue
+		}
+		if mode.Group && language.Group != "" {
+			language, _ = c.Lookup(language.Group)
+		}
+		applied[language.Name] += bytes
+	}
+	return applied
+}
+
+// ParseTypes reads a comma separated list of types such as
+// "programming,markup". An empty list or "all" selects every type.
+func ParseTypes(list string) ([]Type, error) {
+	var types []Type
+	seen := make(map[Type]bool)
+	for _, value := range strings.Split(list, ",") {
+		t := Type(strings.ToLower(strings.TrimSpace(value)))
+		if t == "" || t == "all" || seen[t] {
+			continue
+		}
+		if !(Mode{Types: Types}).includes(t) {
+			return nil, fmt.Errorf("unknown language type %q, want one of programming, markup, data, prose", value)
+		}
+		seen[t] = true
+		types = append(types, t)
+	}
+	if len(types) == len(Types) {
+		return nil, nil
+	}
+	return types, nil
+}
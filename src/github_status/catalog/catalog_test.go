@@ -0,0 +1,66 @@
+package catalog
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefault_parses_the_embedded_catalog(t *testing.T) {
+	language, ok := Default().Lookup("Go")
+
+	assert.True(t, ok)
+	assert.Equal(t, Programming, language.Type)
+	assert.Equal(t, Markup, mustLookup(t, "Jupyter Notebook").Type)
+	assert.Equal(t, "TypeScript", mustLookup(t, "TSX").Group)
+}
+
+func mustLookup(t *testing.T, name string) Language {
+	language, ok := Default().Lookup(name)
+	if !ok {
+		t.Fatalf("%s is not in the catalog", name)
+	}
+	return language
+}
+
+func TestCatalog_Normalize_resolves_aliases_ignoring_case(t *testing.T) {
+	assert.Equal(t, "C++", Default().Normalize("cpp"))
+	assert.Equal(t, "Shell", Default().Normalize("BASH"))
+	assert.Equal(t, "Brainfudge", Default().Normalize("Brainfudge"))
+}
+
+func TestCatalog_Apply_leaves_out_excluded_types(t *testing.T) {
+	languages := map[string]int{"Go": 100, "HTML": 50, "JSON": 10, "Unknown": 1}
+
+	applied := Default().Apply(Mode{Types: []Type{Programming}}, languages)
+
+	assert.Equal(t, map[string]int{"Go": 100}, applied)
+	assert.Equal(t, languages, Default().Apply(Mode{}, languages))
+}
+
+func TestCatalog_Apply_groups_dialects_with_their_parent(t *testing.T) {
+	applied := Default().Apply(Mode{Group: true}, map[string]int{"TypeScript": 100, "TSX": 20, "SCSS": 5})
+
+	assert.Equal(t, map[string]int{"TypeScript": 120, "CSS": 5}, applied)
+}
+
+func TestParse_rejects_unknown_groups_and_duplicates(t *testing.T) {
+	_, err := Parse([]byte(`[{"name": "TSX", "type": "programming", "group": "TypeScript"}]`))
+	assert.NotNil(t, err)
+
+	_, err = Parse([]byte(`[{"name": "Go", "type": "programming"}, {"name": "Golang", "type": "programming", "aliases": ["go"]}]`))
+	assert.NotNil(t, err)
+}
+
+func TestParseTypes_reads_a_list_of_types(t *testing.T) {
+	types, err := ParseTypes("programming, Markup,programming")
+	assert.Nil(t, err)
+	assert.Equal(t, []Type{Programming, Markup}, types)
+
+	types, err = ParseTypes("all")
+	assert.Nil(t, err)
+	assert.Nil(t, types)
+
+	_, err = ParseTypes("code")
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,25 @@
+package crawler
+
+import "github_status/github"
+
+// FetchLanguages returns the FetchFunc the crawler uses for each repo. When
+// details is set, for the dates series are bucketed by, or the filter needs
+// details the listing does not have, it fetches the repo first and only asks
+// for languages if the repo survives the filter.
+func FetchLanguages(client *github.Client, filter Filter, details bool) FetchFunc {
+	return func(repo github.Repo) Result {
+		if (details || filter.NeedsDetails()) && !repo.Detailed() {
+			details, _, err := client.GetRepo(repo.FullName)
+			if err != nil {
+				return Result{Repo: repo, Err: err}
+			}
+			repo = details
+			if reason := filter.Reject(repo); reason != "" {
+				return Result{Repo: repo, Filtered: reason}
+			}
+		}
+
+		languages, _, err := client.GetLanguages(repo.FullName)
+		return Result{Repo: repo, Languages: languages, Err: err}
+	}
+}
@@ -0,0 +1,105 @@
+package crawler
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github_status/github"
+)
+
+const (
+	FilteredFork     = "fork"
+	FilteredArchived = "archived"
+	FilteredStars    = "stars"
+	FilteredSize     = "size"
+	FilteredOwner    = "owner"
+	FilteredCreated  = "created"
+)
+
+// Filter decides which repos are worth a languages request. The /repositories
+// listing already carries the fork flag and owner, so those rules cost
+// nothing. Stars, size, archived and creation date need the repository
+// details, which cost one request, still less than fetching the languages of
+// a repo nobody wants.
+type Filter struct {
+	SkipForks    bool
+	SkipArchived bool
+	MinStars     int
+	MinSize      int
+	AllowOwners  []string
+	DenyOwners   []string
+	CreatedAfter time.Time
+}
+
+func (f Filter) NeedsDetails() bool {
+	return f.SkipArchived || f.MinStars > 0 || f.MinSize > 0 || !f.CreatedAfter.IsZero()
+}
+
+// Reject returns why the repo is filtered out, or "" to keep it. Rules that
+// need details the repo does not have yet are skipped.
+func (f Filter) Reject(repo github.Repo) string {
+	if f.SkipForks && repo.Fork {
+		return FilteredFork
+	}
+	if len(f.AllowOwners) > 0 && !containsOwner(f.AllowOwners, repo.Owner.Login) {
+		return FilteredOwner
+	}
+	if containsOwner(f.DenyOwners, repo.Owner.Login) {
+		return FilteredOwner
+	}
+
+	if !repo.Detailed() {
+		return ""
+	}
+	if f.SkipArchived && repo.Archived {
+		return FilteredArchived
+	}
+	if repo.StargazersCount < f.MinStars {
+		return FilteredStars
+	}
+	if repo.Size < f.MinSize {
+		return FilteredSize
+	}
+	if !f.CreatedAfter.IsZero() && !repo.CreatedAt.After(f.CreatedAfter) {
+		return FilteredCreated
+	}
+	return ""
+}
+
+// Flags are the command line flags that set up f, to record how a result
+// was filtered.
+func (f Filter) Flags() []string {
+	var flags []string
+	if f.SkipForks {
+		flags = append(flags, "-skip-forks")
+	}
+	if f.SkipArchived {
+		flags = append(flags, "-skip-archived")
+	}
+	if f.MinStars > 0 {
+		flags = append(flags, fmt.Sprintf("-min-stars=%d", f.MinStars))
+	}
+	if f.MinSize > 0 {
+		flags = append(flags, fmt.Sprintf("-min-size=%d", f.MinSize))
+	}
+	if len(f.AllowOwners) > 0 {
+		flags = append(flags, "-owners="+strings.Join(f.AllowOwners, ","))
+	}
+	if len(f.DenyOwners) > 0 {
+		flags = append(flags, "-exclude-owners="+strings.Join(f.DenyOwners, ","))
+	}
+	if !f.CreatedAfter.IsZero() {
+		flags = append(flags, "-created-after="+f.CreatedAfter.Format("2006-01-02"))
+	}
+	return flags
+}
+
+func containsOwner(owners []string, owner string) bool {
+	for _, o := range owners {
+		if strings.EqualFold(o, owner) {
+			return true
+		}
+	}
+	return false
+}
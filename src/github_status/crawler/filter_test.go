@@ -0,0 +1,60 @@
+package crawler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github_status/github"
+)
+
+func listed(fullName string, owner string, fork bool) github.Repo {
+	return github.Repo{FullName: fullName, Owner: github.Owner{Login: owner}, Fork: fork}
+}
+
+func detailed(stars int, size int, archived bool, created time.Time) github.Repo {
+	return github.Repo{FullName: "a/b", Owner: github.Owner{Login: "a"}, StargazersCount: stars, Size: size, Archived: archived, CreatedAt: created}
+}
+
+func TestFilter_Reject_keeps_everything_by_default(t *testing.T) {
+	assert.Equal(t, "", Filter{}.Reject(listed("a/b", "a", true)))
+	assert.False(t, Filter{}.NeedsDetails())
+}
+
+func TestFilter_Reject_skips_forks(t *testing.T) {
+	assert.Equal(t, FilteredFork, Filter{SkipForks: true}.Reject(listed("a/b", "a", true)))
+	assert.Equal(t, "", Filter{SkipForks: true}.Reject(listed("a/b", "a", false)))
+}
+
+func TestFilter_Reject_applies_owner_allow_and_deny_lists(t *testing.T) {
+	filter := Filter{AllowOwners: []string{"Golang", "rust-lang"}}
+	assert.Equal(t, "", filter.Reject(listed("golang/go", "golang", false)))
+	assert.Equal(t, FilteredOwner, filter.Reject(listed("a/b", "a", false)))
+
+	filter = Filter{DenyOwners: []string{"spam"}}
+	assert.Equal(t, FilteredOwner, filter.Reject(listed("spam/x", "SPAM", false)))
+}
+
+func TestFilter_Reject_waits_for_details_before_applying_detail_rules(t *testing.T) {
+	filter := Filter{MinStars: 10}
+
+	assert.True(t, filter.NeedsDetails())
+	assert.Equal(t, "", filter.Reject(listed("a/b", "a", false)))
+}
+
+func TestFilter_Reject_applies_detail_rules(t *testing.T) {
+	created := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, FilteredArchived, Filter{SkipArchived: true}.Reject(detailed(0, 0, true, created)))
+	assert.Equal(t, FilteredStars, Filter{MinStars: 10}.Reject(detailed(9, 0, false, created)))
+	assert.Equal(t, FilteredSize, Filter{MinSize: 100}.Reject(detailed(0, 99, false, created)))
+	assert.Equal(t, FilteredCreated, Filter{CreatedAfter: created}.Reject(detailed(0, 0, false, created)))
+	assert.Equal(t, "", Filter{MinStars: 10, CreatedAfter: created}.Reject(detailed(10, 0, false, created.Add(time.Hour))))
+}
+
+func TestFilter_Flags_sets_up_the_same_filter(t *testing.T) {
+	filter := Filter{SkipForks: true, MinStars: 10, DenyOwners: []string{"spam", "bots"}, CreatedAfter: time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)}
+
+	assert.Equal(t, []string{"-skip-forks", "-min-stars=10", "-exclude-owners=spam,bots", "-created-after=2020-01-02"}, filter.Flags())
+	assert.Nil(t, Filter{}.Flags())
+}
@@ -0,0 +1,58 @@
+package crawler
+
+import "github_status/github"
+
+// Fetcher turns a page of repos into results in page order. Pool fetches
+// over REST, one request per repo; GraphQLFetcher batches a whole page.
+type Fetcher interface {
+	Fetch(repos []github.Repo) []Result
+}
+
+// GraphQLFetcher fetches languages and details in one query per batch, so
+// every filter rule is applied without extra requests.
+type GraphQLFetcher struct {
+	client *github.Client
+	filter Filter
+}
+
+func NewGraphQLFetcher(client *github.Client, filter Filter) *GraphQLFetcher {
+	return &GraphQLFetcher{client: client, filter: filter}
+}
+
+func (g *GraphQLFetcher) Fetch(repos []github.Repo) []Result {
+	results := make([]Result, 0, len(repos))
+	for start := 0; start < len(repos); start += github.GraphQLBatchSize {
+		end := start + github.GraphQLBatchSize
+		if end > len(repos) {
+			end = len(repos)
+		}
+		results = append(results, g.fetchBatch(repos[start:end])...)
+	}
+	return results
+}
+
+func (g *GraphQLFetcher) fetchBatch(repos []github.Repo) []Result {
+	fullNames := make([]string, len(repos))
+	for i, repo := range repos {
+		fullNames[i] = repo.FullName
+	}
+
+	results := make([]Result, len(repos))
+	items, _, err := g.client.BatchLanguages(fullNames)
+	if err != nil {
+		for i, repo := range repos {
+			results[i] = Result{Repo: repo, Err: err}
+		}
+		return results
+	}
+
+	for i, item := range items {
+		results[i] = Result{Repo: item.Repo, Languages: item.Languages, Err: item.Err}
+		if item.Err != nil {
+			results[i].Repo = repos[i]
+		} else if reason := g.filter.Reject(item.Repo); reason != "" {
+			results[i] = Result{Repo: item.Repo, Filtered: reason}
+		}
+	}
+	return results
+}
@@ -0,0 +1,52 @@
+package crawler
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github_status/github"
+)
+
+func fakeClient(fakeServer *httptest.Server) *github.Client {
+	client, _ := github.NewEnterpriseClient(fakeServer.URL, nil)
+	return client
+}
+
+func TestGraphQLFetcher_Fetch_filters_on_the_details_it_fetched(t *testing.T) {
+	fakeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data": {
+			"rateLimit": {"cost": 2, "remaining": 4998},
+			"r0": {"nameWithOwner": "a/popular", "stargazerCount": 50, "createdAt": "2020-01-01T00:00:00Z",
+				"languages": {"edges": [{"size": 10, "node": {"name": "Go"}}]}},
+			"r1": {"nameWithOwner": "b/obscure", "stargazerCount": 1, "createdAt": "2020-01-01T00:00:00Z",
+				"languages": {"edges": [{"size": 10, "node": {"name": "C"}}]}}
+		}}`)
+	}))
+	defer fakeServer.Close()
+
+	client := fakeClient(fakeServer)
+	fetcher := NewGraphQLFetcher(client, Filter{MinStars: 10})
+	results := fetcher.Fetch([]github.Repo{{FullName: "a/popular"}, {FullName: "b/obscure"}})
+
+	assert.Equal(t, map[string]int{"Go": 10}, results[0].Languages)
+	assert.Equal(t, FilteredStars, results[1].Filtered)
+	assert.Nil(t, results[1].Languages)
+
+	assert.Equal(t, 2, client.Metrics.GraphQLCost())
+}
+
+func TestGraphQLFetcher_Fetch_reports_a_failed_batch_on_every_repo(t *testing.T) {
+	fakeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer fakeServer.Close()
+
+	results := NewGraphQLFetcher(fakeClient(fakeServer), Filter{}).Fetch([]github.Repo{{FullName: "a/b"}, {FullName: "c/d"}})
+
+	assert.Len(t, results, 2)
+	assert.True(t, github.IsPermanent(results[0].Err))
+	assert.Equal(t, "c/d", results[1].Repo.FullName)
+}
@@ -0,0 +1,79 @@
+package crawler
+
+import (
+	"sync"
+
+	"github_status/github"
+)
+
+type Result struct {
+	Repo      github.Repo
+	Languages map[string]int
+	Filtered  string
+	Err       error
+}
+
+type FetchFunc func(repo github.Repo) Result
+
+type job struct {
+	index   int
+	repo    github.Repo
+	results []Result
+	done    *sync.WaitGroup
+}
+
+// Pool fetches the languages of a page of repos with a fixed number of
+// workers. Fetch only returns once the whole page is done, so callers can
+// checkpoint a page knowing every repo on it has been handled. Once Stop is
+// closed, repos no worker has started on fail with github.ErrStopped.
+type Pool struct {
+	Stop <-chan struct{}
+
+	jobs chan job
+	wait sync.WaitGroup
+}
+
+func NewPool(workers int, fetch FetchFunc) *Pool {
+	if workers < 1 {
+		workers = 1
+	}
+
+	pool := &Pool{jobs: make(chan job)}
+	pool.wait.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer pool.wait.Done()
+			for j := range pool.jobs {
+				j.results[j.index] = fetch(j.repo)
+				j.done.Done()
+			}
+		}()
+	}
+	return pool
+}
+
+func (p *Pool) Fetch(repos []github.Repo) []Result {
+	results := make([]Result, len(repos))
+	done := &sync.WaitGroup{}
+	done.Add(len(repos))
+	stopped := false
+	for i, repo := range repos {
+		if !stopped {
+			select {
+			case p.jobs <- job{index: i, repo: repo, results: results, done: done}:
+				continue
+			case <-p.Stop:
+				stopped = true
+			}
+		}
+		results[i] = Result{Repo: repo, Err: github.ErrStopped}
+		done.Done()
+	}
+	done.Wait()
+	return results
+}
+
+func (p *Pool) Close() {
+	close(p.jobs)
+	p.wait.Wait()
+}
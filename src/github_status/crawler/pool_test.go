@@ -0,0 +1,72 @@
+package crawler
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github_status/github"
+)
+
+func TestPool_Fetch_returns_results_in_page_order(t *testing.T) {
+	pool := NewPool(4, func(repo github.Repo) Result {
+		if repo.FullName == "a/first" {
+			time.Sleep(10 * time.Millisecond)
+		}
+		return Result{Repo: repo, Languages: map[string]int{repo.FullName: 1}}
+	})
+	defer pool.Close()
+
+	results := pool.Fetch([]github.Repo{{FullName: "a/first"}, {FullName: "b/second"}, {FullName: "c/third"}})
+
+	assert.Len(t, results, 3)
+	for i, name := range []string{"a/first", "b/second", "c/third"} {
+		assert.Equal(t, name, results[i].Repo.FullName)
+		assert.Equal(t, map[string]int{name: 1}, results[i].Languages)
+	}
+}
+
+func TestPool_Fetch_never_runs_more_than_the_configured_workers(t *testing.T) {
+	var mutex sync.Mutex
+	running, most := 0, 0
+	pool := NewPool(2, func(repo github.Repo) Result {
+		mutex.Lock()
+		running++
+		if running > most {
+			most = running
+		}
+		mutex.Unlock()
+
+		time.Sleep(5 * time.Millisecond)
+
+		mutex.Lock()
+		running--
+		mutex.Unlock()
+		return Result{Repo: repo}
+	})
+	defer pool.Close()
+
+	pool.Fetch(make([]github.Repo, 10))
+
+	assert.Equal(t, 2, most)
+}
+
+func TestPool_Fetch_drops_repos_not_started_when_stopped(t *testing.T) {
+	stop := make(chan struct{})
+	pool := NewPool(1, func(repo github.Repo) Result {
+		close(stop)
+		// Stay busy so the rest of the page can only be dropped.
+		time.Sleep(10 * time.Millisecond)
+		return Result{Repo: repo}
+	})
+	pool.Stop = stop
+	defer pool.Close()
+
+	results := pool.Fetch([]github.Repo{{FullName: "a/first"}, {FullName: "b/second"}, {FullName: "c/third"}})
+
+	assert.Nil(t, results[0].Err)
+	assert.Equal(t, github.ErrStopped, results[1].Err)
+	assert.Equal(t, github.ErrStopped, results[2].Err)
+	assert.Equal(t, "c/third", results[2].Repo.FullName)
+}
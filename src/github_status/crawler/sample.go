@@ -0,0 +1,155 @@
+package crawler
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+
+	"github_status/github"
+	"github_status/stats"
+)
+
+// repositoriesPerPage is how many repos /repositories returns at most.
+const repositoriesPerPage = 100
+
+// maxRepositoryID bounds the search for the newest repo, far above any ID
+// GitHub has handed out, so an API that never runs out of repos is caught.
+const maxRepositoryID = 1 << 40
+
+// Estimate is what a Sample tells about all of GitHub. Population is the
+// estimated number of public repos, give or take Margin at 95% confidence.
+type Estimate struct {
+	MaxID      int64   `json:"max_id"`
+	Pages      int     `json:"pages"`
+	TotalPages int     `json:"total_pages"`
+	Repos      int     `json:"repos"`
+	Population float64 `json:"population"`
+	Margin     float64 `json:"margin"`
+}
+
+// Sample reads /repositories at random since offsets across the whole ID
+// space instead of walking it from the oldest repo, so a few thousand pages
+// already describe all of GitHub. Each page covers the repos after its
+// offset, which makes every repo about equally likely to be drawn as long as
+// deleted IDs are spread evenly. Repos drawn twice are only returned once.
+type Sample struct {
+	Pages int
+	MaxID int64
+
+	list func(since int64) ([]github.Repo, github.GitHubHeader, error)
+	rand *rand.Rand
+	seen map[int64]bool
+
+	// found and spans are the repos and the IDs each drawn page covered,
+	// from which the density of repos in the ID space is estimated.
+	found []float64
+	spans []float64
+}
+
+func NewSample(client *github.Client, pages int, seed int64) *Sample {
+	return &Sample{
+		Pages: pages,
+		list: func(since int64) ([]github.Repo, github.GitHubHeader, error) {
+			return client.GetRepos(fmt.Sprintf("repositories?since=%d", since))
+		},
+		rand: rand.New(rand.NewSource(seed)),
+		seen: make(map[int64]bool),
+	}
+}
+
+// DiscoverMaxID finds the ID of the newest repo by doubling since until a
+// page comes back empty and then bisecting, in about 2*log2(MaxID) requests.
+func (s *Sample) DiscoverMaxID() (int64, error) {
+	low := int64(0)
+	lowPage, _, err := s.list(low)
+	if err != nil {
+		return 0, err
+	}
+	if len(lowPage) == 0 {
+		return 0, errors.New("sample: /repositories is empty")
+	}
+
+	high := int64(1 << 20)
+	for {
+		if high > maxRepositoryID {
+			return 0, fmt.Errorf("sample: /repositories still has repos after ID %d", maxRepositoryID)
+		}
+		page, _, err := s.list(high)
+		if err != nil {
+			return 0, err
+		}
+		if len(page) == 0 {
+			break
+		}
+		low, lowPage, high = high, page, high*2
+	}
+
+	for len(lowPage) >= repositoriesPerPage && high-low > 1 {
+		mid := low + (high-low)/2
+		page, _, err := s.list(mid)
+		if err != nil {
+			return 0, err
+		}
+		if len(page) == 0 {
+			high = mid
+		} else {
+			low, lowPage = mid, page
+		}
+	}
+
+	s.MaxID = lowPage[len(lowPage)-1].ID
+	return s.MaxID, nil
+}
+
+func (s *Sample) Next() (Page, error) {
+	if len(s.spans) >= s.Pages {
+		return Page{}, io.EOF
+	}
+	if s.MaxID == 0 {
+		if _, err := s.DiscoverMaxID(); err != nil {
+			return Page{}, err
+		}
+	}
+
+	since := s.rand.Int63n(s.MaxID)
+	repos, header, err := s.list(since)
+	if err != nil {
+		return Page{}, err
+	}
+
+	span := s.MaxID - since
+	if len(repos) >= repositoriesPerPage {
+		span = repos[len(repos)-1].ID - since
+	}
+	s.found = append(s.found, float64(len(repos)))
+	s.spans = append(s.spans, float64(span))
+
+	fresh := repos[:0:0]
+	for _, repo := range repos {
+		if !s.seen[repo.ID] {
+			s.seen[repo.ID] = true
+			fresh = append(fresh, repo)
+		}
+	}
+	return Page{Repos: fresh, Header: header}, nil
+}
+
+// TotalPages is the number of pages the sample draws.
+func (s *Sample) TotalPages() int {
+	return s.Pages
+}
+
+// Estimate scales the density of repos on the drawn pages up to the whole ID
+// space.
+func (s *Sample) Estimate() Estimate {
+	density, stderr := stats.Ratio(s.found, s.spans)
+	return Estimate{
+		MaxID:      s.MaxID,
+		Pages:      len(s.spans),
+		TotalPages: s.Pages,
+		Repos:      len(s.seen),
+		Population: density * float64(s.MaxID),
+		Margin:     stats.Z95 * stderr * float64(s.MaxID),
+	}
+}
@@ -0,0 +1,71 @@
+package crawler
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github_status/github"
+)
+
+// fakeRepositories pretends every other ID up to maxID is a public repo and
+// answers like /repositories?since=.
+func fakeRepositories(maxID int64, calls *int) func(int64) ([]github.Repo, github.GitHubHeader, error) {
+	return func(since int64) ([]github.Repo, github.GitHubHeader, error) {
+		*calls++
+		var repos []github.Repo
+		for id := since + 1; id <= maxID && len(repos) < repositoriesPerPage; id++ {
+			if id%2 == 0 {
+				repos = append(repos, github.Repo{ID: id})
+			}
+		}
+		return repos, github.GitHubHeader{}, nil
+	}
+}
+
+func newFakeSample(maxID int64, pages int, calls *int) *Sample {
+	return &Sample{
+		Pages: pages,
+		list:  fakeRepositories(maxID, calls),
+		rand:  rand.New(rand.NewSource(1)),
+		seen:  make(map[int64]bool),
+	}
+}
+
+func TestSample_DiscoverMaxID_finds_the_newest_repo(t *testing.T) {
+	calls := 0
+	sample := newFakeSample(123456790, 1, &calls)
+
+	maxID, err := sample.DiscoverMaxID()
+
+	assert.Nil(t, err)
+	assert.Equal(t, int64(123456790), maxID)
+	assert.True(t, calls < 64)
+}
+
+func TestSample_returns_each_repo_once_and_stops_after_its_pages(t *testing.T) {
+	calls := 0
+	sample := newFakeSample(2000, 50, &calls)
+
+	repos := collect(t, sample)
+
+	ids := make(map[int64]bool)
+	for _, repo := range repos {
+		assert.False(t, ids[repo.ID])
+		ids[repo.ID] = true
+	}
+	assert.Equal(t, 50, sample.Estimate().Pages)
+	assert.Equal(t, len(repos), sample.Estimate().Repos)
+}
+
+func TestSample_Estimate_scales_the_density_to_the_id_space(t *testing.T) {
+	calls := 0
+	sample := newFakeSample(10000000, 20, &calls)
+
+	collect(t, sample)
+	estimate := sample.Estimate()
+
+	assert.Equal(t, int64(10000000), estimate.MaxID)
+	assert.InDelta(t, 5000000, estimate.Population, 50000)
+	assert.True(t, estimate.Margin < estimate.Population/100)
+}
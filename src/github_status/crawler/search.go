@@ -0,0 +1,104 @@
+package crawler
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github_status/github"
+)
+
+// GitHubFounded is the earliest useful creation date for search slices.
+var GitHubFounded = time.Date(2007, 10, 1, 0, 0, 0, 0, time.UTC)
+
+type dateRange struct {
+	from, to time.Time
+}
+
+func (d dateRange) qualifier() string {
+	return fmt.Sprintf("created:%s..%s", d.from.UTC().Format(time.RFC3339), d.to.UTC().Format(time.RFC3339))
+}
+
+// split halves the range, returning false once it is down to a second.
+func (d dateRange) split() (dateRange, dateRange, bool) {
+	if d.to.Sub(d.from) < 2*time.Second {
+		return d, d, false
+	}
+	mid := d.from.Add(d.to.Sub(d.from) / 2).Truncate(time.Second)
+	return dateRange{d.from, mid}, dateRange{mid.Add(time.Second), d.to}, true
+}
+
+// Search pages through /search/repositories for Query. Because the search
+// API stops at 1000 results, any created: range with more matches is split
+// in half until every slice fits, so the whole population is visited.
+type Search struct {
+	Query  string
+	search func(query string, page int) (github.SearchResult, github.GitHubHeader, error)
+
+	pending []dateRange
+	current dateRange
+	page    int
+	total   int
+}
+
+func NewSearch(client *github.Client, query string, from time.Time, to time.Time) *Search {
+	return &Search{Query: query, search: client.SearchRepos, pending: []dateRange{{from, to}}}
+}
+
+func (s *Search) query(r dateRange) string {
+	if s.Query == "" {
+		return r.qualifier()
+	}
+	return s.Query + " " + r.qualifier()
+}
+
+func (s *Search) Next() (Page, error) {
+	for {
+		if s.page > 0 {
+			if page, ok, err := s.nextPage(); ok || err != nil {
+				return page, err
+			}
+		}
+
+		if len(s.pending) == 0 {
+			return Page{}, io.EOF
+		}
+		r := s.pending[0]
+		s.pending = s.pending[1:]
+
+		result, header, err := s.search(s.query(r), 1)
+		if err != nil {
+			s.pending = append([]dateRange{r}, s.pending...)
+			return Page{}, err
+		}
+
+		if result.TotalCount > github.SearchResultLimit {
+			if first, second, ok := r.split(); ok {
+				s.pending = append([]dateRange{first, second}, s.pending...)
+				continue
+			}
+		}
+
+		s.current, s.page, s.total = r, 1, result.TotalCount
+		return Page{Repos: result.Items, Header: header}, nil
+	}
+}
+
+// nextPage continues the current slice, reporting false once it is done.
+func (s *Search) nextPage() (Page, bool, error) {
+	if s.page*github.SearchPerPage >= s.total || s.page*github.SearchPerPage >= github.SearchResultLimit {
+		s.page = 0
+		return Page{}, false, nil
+	}
+
+	result, header, err := s.search(s.query(s.current), s.page+1)
+	if err != nil {
+		return Page{}, false, err
+	}
+	s.page++
+	if len(result.Items) == 0 {
+		s.page = 0
+		return Page{}, false, nil
+	}
+	return Page{Repos: result.Items, Header: header}, true, nil
+}
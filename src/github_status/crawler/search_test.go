@@ -0,0 +1,92 @@
+package crawler
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github_status/github"
+)
+
+// fakeSearch pretends one repo was created every hour and answers queries
+// with a created: range like the search API does.
+func fakeSearch(queries *[]string) func(string, int) (github.SearchResult, github.GitHubHeader, error) {
+	return func(query string, page int) (github.SearchResult, github.GitHubHeader, error) {
+		*queries = append(*queries, fmt.Sprintf("%s page=%d", query, page))
+
+		bounds := strings.SplitN(query[strings.Index(query, "created:")+len("created:"):], "..", 2)
+		from, _ := time.Parse(time.RFC3339, bounds[0])
+		to, _ := time.Parse(time.RFC3339, bounds[1])
+
+		var matches []github.Repo
+		for at := from.Truncate(time.Hour); !at.After(to); at = at.Add(time.Hour) {
+			if !at.Before(from) {
+				matches = append(matches, github.Repo{FullName: at.Format(time.RFC3339), CreatedAt: at})
+			}
+		}
+
+		result := github.SearchResult{TotalCount: len(matches)}
+		start := (page - 1) * github.SearchPerPage
+		for i := start; i < len(matches) && i < start+github.SearchPerPage && i < github.SearchResultLimit; i++ {
+			result.Items = append(result.Items, matches[i])
+		}
+		return result, github.GitHubHeader{}, nil
+	}
+}
+
+func collect(t *testing.T, source Source) []github.Repo {
+	var repos []github.Repo
+	for {
+		page, err := source.Next()
+		if err == io.EOF {
+			return repos
+		}
+		assert.Nil(t, err)
+		repos = append(repos, page.Repos...)
+	}
+}
+
+func TestSearch_pages_through_a_slice_under_the_cap(t *testing.T) {
+	var queries []string
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	search := &Search{Query: "stars:>100", search: fakeSearch(&queries), pending: []dateRange{{from, from.Add(249 * time.Hour)}}}
+
+	repos := collect(t, search)
+
+	assert.Len(t, repos, 250)
+	assert.Equal(t, []string{
+		"stars:>100 created:2024-01-01T00:00:00Z..2024-01-11T09:00:00Z page=1",
+		"stars:>100 created:2024-01-01T00:00:00Z..2024-01-11T09:00:00Z page=2",
+		"stars:>100 created:2024-01-01T00:00:00Z..2024-01-11T09:00:00Z page=3",
+	}, queries)
+}
+
+func TestSearch_splits_ranges_over_the_cap_and_visits_every_repo_once(t *testing.T) {
+	var queries []string
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	search := &Search{search: fakeSearch(&queries), pending: []dateRange{{from, from.Add(2999 * time.Hour)}}}
+
+	repos := collect(t, search)
+
+	assert.Len(t, repos, 3000)
+	seen := make(map[string]bool)
+	for _, repo := range repos {
+		assert.False(t, seen[repo.FullName])
+		seen[repo.FullName] = true
+	}
+}
+
+func TestDateRange_split_stops_at_one_second(t *testing.T) {
+	at := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	_, _, ok := dateRange{at, at.Add(time.Second)}.split()
+	first, second, split := dateRange{at, at.Add(3 * time.Second)}.split()
+
+	assert.False(t, ok)
+	assert.True(t, split)
+	assert.Equal(t, dateRange{at, at.Add(time.Second)}, first)
+	assert.Equal(t, dateRange{at.Add(2 * time.Second), at.Add(3 * time.Second)}, second)
+}
@@ -0,0 +1,56 @@
+package crawler
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+
+	"github_status/github"
+)
+
+type Page struct {
+	Repos  []github.Repo
+	Header github.GitHubHeader
+}
+
+// Source produces the pages of repos a crawl aggregates. Next returns io.EOF
+// once there are no pages left.
+type Source interface {
+	Next() (Page, error)
+}
+
+// Repositories walks the /repositories firehose by its since cursor.
+type Repositories struct {
+	Since  int64
+	client *github.Client
+	done   bool
+}
+
+func NewRepositories(client *github.Client, since int64) *Repositories {
+	return &Repositories{Since: since, client: client}
+}
+
+func (r *Repositories) path() string {
+	if r.Since == 0 {
+		return "repositories"
+	}
+	return fmt.Sprintf("repositories?since=%d", r.Since)
+}
+
+func (r *Repositories) Next() (Page, error) {
+	if r.done {
+		return Page{}, io.EOF
+	}
+
+	repos, header, err := r.client.GetRepos(r.path())
+	if err != nil {
+		return Page{}, err
+	}
+
+	if header.Next == nil {
+		r.done = true
+	} else {
+		r.Since, _ = strconv.ParseInt(header.Next.Query().Get("since"), 10, 64)
+	}
+	return Page{Repos: repos, Header: header}, nil
+}
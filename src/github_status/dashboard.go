@@ -0,0 +1,290 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github_status/stats"
+)
+
+const (
+	DefaultTopLanguages = 15
+
+	defaultWidth  = 80
+	defaultHeight = 24
+)
+
+// Dashboard redraws a snapshot of the crawl in place: a status header and
+// the top languages by share of bytes, with the rest summed up as "Other".
+type Dashboard struct {
+	Out  io.Writer
+	TopN int
+	// Weighting is what the shares are of, raw bytes by default.
+	Weighting stats.Weighting
+
+	// Width and Height override the size of the terminal on Out.
+	Width  int
+	Height int
+
+	lines int
+}
+
+func NewDashboard(out io.Writer) *Dashboard {
+	return &Dashboard{Out: out, TopN: DefaultTopLanguages, Weighting: stats.Raw}
+}
+
+// Draw replaces the previous frame with one for snapshot and, if not nil,
+// summary. The frame is written at once and each line is cleared as it is
+// overwritten, so the screen does not flicker.
+func (d *Dashboard) Draw(snapshot *Snapshot, summary *stats.Summary, now time.Time) error {
+	lines := d.Render(snapshot, summary, now)
+
+	var frame bytes.Buffer
+	if d.lines > 0 {
+		fmt.Fprintf(&frame, "\r\033[%dA", d.lines)
+	}
+	for _, line := range lines {
+		frame.WriteString(line)
+		frame.WriteString("\033[K\n")
+	}
+	frame.WriteString("\033[J")
+	d.lines = len(lines)
+
+	_, err := d.Out.Write(frame.Bytes())
+	return err
+}
+
+func (d *Dashboard) size() (int, int) {
+	width, height := d.Width, d.Height
+	if width > 0 && height > 0 {
+		return width, height
+	}
+	if f, ok := d.Out.(*os.File); ok {
+		if w, h, ok := terminalSize(f); ok {
+			if width == 0 {
+				width = w
+			}
+			if height == 0 {
+				height = h
+			}
+		}
+	}
+	if width == 0 {
+		width = defaultWidth
+	}
+	if height == 0 {
+		height = defaultHeight
+	}
+	return width, height
+}
+
+// Render lays out the frame for snapshot to fit the terminal.
+func (d *Dashboard) Render(snapshot *Snapshot, summary *stats.Summary, now time.Time) []string {
+	width, height := d.size()
+
+	var lines []string
+	status := fmt.Sprintf("Repos: %d (%.1f/min)", snapshot.Repos, throughput(snapshot, now))
+	if snapshot.TotalPages > 0 {
+		status += fmt.Sprintf("   Pages: %d of %d", snapshot.Pages, snapshot.TotalPages)
+	} else {
+		status += fmt.Sprintf("   Pages: %d", snapshot.Pages)
+	}
+	lines = append(lines, status)
+
+	limit := fmt.Sprintf("Rate limit: %d remaining", snapshot.RateLimitRemaining)
+	if snapshot.RateLimitReset.After(now) {
+		limit += ", resets in " + countdown(snapshot.RateLimitReset.Sub(now))
+	}
+	lines = append(lines, limit)
+
+	if snapshot.Sample != nil && snapshot.Sample.Pages > 0 {
+		lines = append(lines, fmt.Sprintf("Estimate: %s ± %s public repos (IDs up to %d)",
+			formatCount(snapshot.Sample.Population), formatCount(snapshot.Sample.Margin), snapshot.Sample.MaxID))
+	}
+
+	if len(snapshot.Filtered) > 0 {
+		reasons := make([]string, 0, len(snapshot.Filtered))
+		for reason, count := range snapshot.Filtered {
+			reasons = append(reasons, fmt.Sprintf("%s %d", reason, count))
+		}
+		sort.Strings(reasons)
+		lines = append(lines, "Filtered: "+strings.Join(reasons, ", "))
+	}
+	if d.Weighting != stats.Raw && d.Weighting != "" {
+		lines = append(lines, fmt.Sprintf("Weighting: %s, this crawl only", d.Weighting))
+		// The confidence intervals are of byte shares.
+		summary = nil
+	}
+	lines = append(lines, strings.Repeat("_", min(width, 40)))
+
+	// Leave a line for the cursor below the frame.
+	rows := height - len(lines) - 1
+	if d.TopN > 0 && rows > d.TopN+1 {
+		rows = d.TopN + 1
+	}
+	for i := range lines {
+		lines[i] = truncate(lines[i], width)
+	}
+	return append(lines, languageRows(snapshot.Shares(d.Weighting), summary, rows, width)...)
+}
+
+type languageShare struct {
+	Name  string
+	Bytes int
+	Share int // in tenths of a percent
+}
+
+// topLanguages returns up to n languages by bytes, the last one being "Other"
+// for all those that did not fit, with shares that add up to 100%.
+func topLanguages(languages map[string]int, n int) []languageShare {
+	var all []languageShare
+	for name, bytes := range languages {
+		if bytes > 0 {
+			all = append(all, languageShare{Name: name, Bytes: bytes})
+		}
+	}
+	sort.Slice(all, func(i, j int) bool {
+		if all[i].Bytes != all[j].Bytes {
+			return all[i].Bytes > all[j].Bytes
+		}
+		return all[i].Name < all[j].Name
+	})
+
+	if n < 1 {
+		n = 1
+	}
+	if len(all) > n {
+		other := languageShare{Name: "Other"}
+		for _, language := range all[n-1:] {
+			other.Bytes += language.Bytes
+		}
+		all = append(all[:n-1], other)
+	}
+
+	values := make([]int, len(all))
+	for i, language := range all {
+		values[i] = language.Bytes
+	}
+	for i, share := range largestRemainder(values, 1000) {
+		all[i].Share = share
+	}
+	return all
+}
+
+// largestRemainder splits units proportionally to values, handing the units
+// lost to rounding down to the largest remainders, so that the parts always
+// add up to units.
+func largestRemainder(values []int, units int) []int {
+	total := 0
+	for _, value := range values {
+		total += value
+	}
+	parts := make([]int, len(values))
+	if total == 0 {
+		return parts
+	}
+
+	remainders := make([]int, len(values))
+	left := units
+	for i, value := range values {
+		exact := int64(value) * int64(units)
+		parts[i] = int(exact / int64(total))
+		remainders[i] = int(exact % int64(total))
+		left -= parts[i]
+	}
+
+	order := make([]int, len(values))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return remainders[order[i]] > remainders[order[j]]
+	})
+	for i := 0; i < left; i++ {
+		parts[order[i]]++
+	}
+	return parts
+}
+
+// languageRows draws a bar per language. With a summary each row also shows
+// the margin of the byte share at 95% confidence and the share of repos that
+// use the language.
+func languageRows(languages map[string]int, summary *stats.Summary, rows int, width int) []string {
+	if rows < 1 {
+		return nil
+	}
+	shares := topLanguages(languages, rows)
+
+	nameWidth := 0
+	for _, language := range shares {
+		if len(language.Name) > nameWidth {
+			nameWidth = len(language.Name)
+		}
+	}
+	if nameWidth > width/3 {
+		nameWidth = width / 3
+	}
+	const percentWidth = 7 // " 100.0%"
+	const statsWidth = 13  // " ±12.3  30.2%"
+	barWidth := width - nameWidth - percentWidth - 2
+	if summary != nil && barWidth > statsWidth+10 {
+		barWidth -= statsWidth
+	} else {
+		summary = nil
+	}
+
+	lines := make([]string, 0, len(shares))
+	for _, language := range shares {
+		line := fmt.Sprintf("%-*s ", nameWidth, truncate(language.Name, nameWidth))
+		if barWidth > 0 {
+			line += fmt.Sprintf("%-*s ", barWidth, strings.Repeat("█", language.Share*barWidth/1000))
+		}
+		line += fmt.Sprintf("%5s%%", strconv.FormatFloat(float64(language.Share)/10, 'f', 1, 64))
+		if summary != nil {
+			if s, ok := summary.Languages[language.Name]; ok {
+				margin := (s.ByteShareCI.High - s.ByteShareCI.Low) / 2 * 100
+				line += fmt.Sprintf(" ±%4.1f %5.1f%%", margin, s.RepoShare*100)
+			}
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func throughput(snapshot *Snapshot, now time.Time) float64 {
+	elapsed := now.Sub(snapshot.StartedAt).Minutes()
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(snapshot.Repos) / elapsed
+}
+
+// countdown formats d as 1h02m03s, 2m03s or 3s.
+func countdown(d time.Duration) string {
+	d = d.Round(time.Second)
+	hours, minutes, seconds := int(d.Hours()), int(d.Minutes())%60, int(d.Seconds())%60
+	switch {
+	case hours > 0:
+		return fmt.Sprintf("%dh%02dm%02ds", hours, minutes, seconds)
+	case minutes > 0:
+		return fmt.Sprintf("%dm%02ds", minutes, seconds)
+	}
+	return fmt.Sprintf("%ds", seconds)
+}
+
+func truncate(s string, width int) string {
+	runes := []rune(s)
+	if len(runes) <= width {
+		return s
+	}
+	if width <= 0 {
+		return ""
+	}
+	return string(runes[:width])
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLargestRemainder_adds_up_to_the_units(t *testing.T) {
+	parts := largestRemainder([]int{1, 1, 1}, 1000)
+
+	assert.Equal(t, []int{334, 333, 333}, parts)
+}
+
+func TestLargestRemainder_gives_small_values_their_share(t *testing.T) {
+	parts := largestRemainder([]int{9990, 6, 4}, 1000)
+
+	assert.Equal(t, []int{999, 1, 0}, parts)
+}
+
+func TestTopLanguages_groups_the_rest_as_other(t *testing.T) {
+	shares := topLanguages(map[string]int{"Go": 50, "C": 30, "Rust": 15, "Perl": 5}, 3)
+
+	assert.Equal(t, []languageShare{
+		{Name: "Go", Bytes: 50, Share: 500},
+		{Name: "C", Bytes: 30, Share: 300},
+		{Name: "Other", Bytes: 20, Share: 200},
+	}, shares)
+}
+
+func TestTopLanguages_sorts_by_share(t *testing.T) {
+	shares := topLanguages(map[string]int{"A": 1, "B": 3, "C": 2}, 10)
+
+	assert.Equal(t, "B", shares[0].Name)
+	assert.Equal(t, "C", shares[1].Name)
+	assert.Equal(t, "A", shares[2].Name)
+}
+
+func TestDashboard_Render_fits_the_terminal(t *testing.T) {
+	now := time.Now()
+	snapshot := &Snapshot{
+		Languages:          map[string]int{"Go": 60, "JavaScript": 30, "C": 5, "Perl": 5},
+		Repos:              120,
+		Pages:              2,
+		RateLimitRemaining: 4000,
+		RateLimitReset:     now.Add(12*time.Minute + 3*time.Second),
+		StartedAt:          now.Add(-2 * time.Minute),
+	}
+	dashboard := &Dashboard{TopN: 10, Width: 50, Height: 7}
+
+	lines := dashboard.Render(snapshot, nil, now)
+
+	assert.Len(t, lines, 6)
+	assert.Equal(t, "Repos: 120 (60.0/min)   Pages: 2", lines[0])
+	assert.Equal(t, "Rate limit: 4000 remaining, resets in 12m03s", lines[1])
+	assert.True(t, strings.HasPrefix(lines[3], "Go "))
+	assert.True(t, strings.HasSuffix(lines[3], " 60.0%"))
+	assert.True(t, strings.HasPrefix(lines[5], "Other "))
+	for _, line := range lines {
+		assert.True(t, len([]rune(line)) <= 50, line)
+	}
+}
+
+func TestDashboard_Draw_redraws_over_the_previous_frame(t *testing.T) {
+	var out bytes.Buffer
+	dashboard := &Dashboard{Out: &out, Width: 40, Height: 10}
+	snapshot := &Snapshot{Languages: map[string]int{"Go": 1}}
+
+	dashboard.Draw(snapshot, nil, time.Now())
+	out.Reset()
+	dashboard.Draw(snapshot, nil, time.Now())
+
+	assert.True(t, strings.HasPrefix(out.String(), "\r\033[4A"))
+}
+
+func TestCountdown_formats_the_time_until_reset(t *testing.T) {
+	assert.Equal(t, "1h02m03s", countdown(time.Hour+2*time.Minute+3*time.Second))
+	assert.Equal(t, "2m03s", countdown(2*time.Minute+3*time.Second))
+	assert.Equal(t, "3s", countdown(3*time.Second))
+}
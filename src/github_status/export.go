@@ -0,0 +1,554 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github_status/catalog"
+	"github_status/crawler"
+	"github_status/stats"
+	"github_status/storage"
+)
+
+// exportSchema is the version of the columns and fields of every export. It
+// changes whenever one is renamed, removed or changes meaning; new ones are
+// only added at the end.
+const exportSchema = 1
+
+// exportFormats are the formats export writes by default, each to its own
+// files.
+var exportFormats = []string{"csv", "jsonl", "md", "html"}
+
+// exportHeader records how an export was made, so that it can be made again
+// from the same data.
+type exportHeader struct {
+	Schema      int       `json:"schema"`
+	GeneratedAt time.Time `json:"generated_at"`
+	Source      string    `json:"source"`
+	// Filters are the flags that filtered the repos, see crawler.Filter.
+	Filters   []string `json:"filters"`
+	Types     string   `json:"types"`
+	Group     bool     `json:"group"`
+	Weighting string   `json:"weighting"`
+	WeightCap int      `json:"weight_cap"`
+	// Repos and Filtered are zero and the times the repos were created nil
+	// when the export comes from a checkpoint, which has no repos; FetchedTo
+	// is when it was saved then.
+	Repos       int        `json:"repos"`
+	Filtered    int        `json:"filtered"`
+	FetchedFrom *time.Time `json:"fetched_from"`
+	FetchedTo   *time.Time `json:"fetched_to"`
+	CreatedFrom *time.Time `json:"created_from"`
+	CreatedTo   *time.Time `json:"created_to"`
+	// Undetailed are the filtered repos that were stored without the details
+	// the filters need, so they could not be checked and were left out.
+	Undetailed int `json:"undetailed"`
+	// Processed are the repos a crawl went through, filtered or not, when the
+	// export comes from its checkpoint.
+	Processed int `json:"processed"`
+}
+
+// fields are the header as names and values in order, for formats without
+// nested objects.
+func (h exportHeader) fields() [][2]string {
+	date := func(t *time.Time) string {
+		if t == nil {
+			return ""
+		}
+		return exportTime(*t)
+	}
+	return [][2]string{
+		{"schema", strconv.Itoa(h.Schema)},
+		{"generated_at", exportTime(h.GeneratedAt)},
+		{"source", h.Source},
+		{"filters", strings.Join(h.Filters, " ")},
+		{"types", h.Types},
+		{"group", strconv.FormatBool(h.Group)},
+		{"weighting", h.Weighting},
+		{"weight_cap", strconv.Itoa(h.WeightCap)},
+		{"repos", strconv.Itoa(h.Repos)},
+		{"filtered", strconv.Itoa(h.Filtered)},
+		{"fetched_from", date(h.FetchedFrom)},
+		{"fetched_to", date(h.FetchedTo)},
+		{"created_from", date(h.CreatedFrom)},
+		{"created_to", date(h.CreatedTo)},
+		{"undetailed", strconv.Itoa(h.Undetailed)},
+		{"processed", strconv.Itoa(h.Processed)},
+	}
+}
+
+// cover widens the time ranges of the header to include repo.
+func (h *exportHeader) cover(repo storage.RepoLanguages) {
+	widen := func(from, to **time.Time, t time.Time) {
+		if t.IsZero() {
+			return
+		}
+		if *from == nil || t.Before(**from) {
+			*from = &t
+		}
+		if *to == nil || t.After(**to) {
+			*to = &t
+		}
+	}
+	widen(&h.FetchedFrom, &h.FetchedTo, repo.FetchedAt)
+	widen(&h.CreatedFrom, &h.CreatedTo, repo.CreatedAt)
+}
+
+type exportLanguage struct {
+	Language  string  `json:"language"`
+	Bytes     int     `json:"bytes"`
+	ByteShare float64 `json:"byte_share"`
+	Repos     int     `json:"repos"`
+	RepoShare float64 `json:"repo_share"`
+	// Shares is the share of the language under every weighting the repos
+	// were weighed by. A checkpoint only has raw bytes.
+	Shares map[stats.Weighting]float64 `json:"shares"`
+}
+
+// exportLanguages lists the languages of snapshot by bytes.
+func exportLanguages(snapshot *Snapshot) []exportLanguage {
+	totalBytes := 0
+	for _, bytes := range snapshot.Languages {
+		totalBytes += bytes
+	}
+	weightTotals := make(map[stats.Weighting]float64)
+	for w, weights := range snapshot.Weighted {
+		for _, weight := range weights {
+			weightTotals[w] += weight
+		}
+	}
+
+	languages := make([]exportLanguage, 0, len(snapshot.Languages))
+	for name, bytes := range snapshot.Languages {
+		l := exportLanguage{Language: name, Bytes: bytes, Repos: snapshot.LanguageRepos[name], Shares: make(map[stats.Weighting]float64)}
+		if totalBytes > 0 {
+			l.ByteShare = float64(bytes) / float64(totalBytes)
+		}
+		if snapshot.Repos > 0 {
+			l.RepoShare = float64(l.Repos) / float64(snapshot.Repos)
+		}
+		for w, weights := range snapshot.Weighted {
+			l.Shares[w] = 0
+			if weightTotals[w] > 0 {
+				l.Shares[w] = weights[name] / weightTotals[w]
+			}
+		}
+		l.Shares[stats.Raw] = l.ByteShare
+		languages = append(languages, l)
+	}
+	sort.Slice(languages, func(i, j int) bool {
+		if languages[i].Bytes != languages[j].Bytes {
+			return languages[i].Bytes > languages[j].Bytes
+		}
+		return languages[i].Language < languages[j].Language
+	})
+	return languages
+}
+
+// exportRepo is a stored repo with its languages as counted.
+type exportRepo struct {
+	storage.RepoLanguages
+	Bytes int `json:"bytes"`
+}
+
+// eachRepo visits the repos to export in a stable order.
+type eachRepo func(func(exportRepo) error) error
+
+func shareString(share float64) string {
+	return strconv.FormatFloat(share, 'f', 6, 64)
+}
+
+// weightedShareString leaves the share of a weighting the repos were not
+// weighed by empty rather than claim it is 0.
+func weightedShareString(shares map[stats.Weighting]float64, w stats.Weighting) string {
+	share, ok := shares[w]
+	if !ok {
+		return ""
+	}
+	return shareString(share)
+}
+
+// writeCSVHeader writes the header as comment lines, which CSV readers such
+// as pandas skip with comment="#".
+func writeCSVHeader(w io.Writer, header exportHeader) {
+	for _, field := range header.fields() {
+		fmt.Fprintf(w, "# %s: %s\n", field[0], field[1])
+	}
+}
+
+func writeLanguagesCSV(w io.Writer, header exportHeader, languages []exportLanguage) error {
+	writeCSVHeader(w, header)
+	out := csv.NewWriter(w)
+	columns := []string{"language", "bytes", "byte_share", "repos", "repo_share"}
+	for _, weighting := range stats.Weightings {
+		columns = append(columns, "share_"+string(weighting))
+	}
+	out.Write(columns)
+	for _, l := range languages {
+		row := []string{l.Language, strconv.Itoa(l.Bytes), shareString(l.ByteShare), strconv.Itoa(l.Repos), shareString(l.RepoShare)}
+		for _, weighting := range stats.Weightings {
+			row = append(row, weightedShareString(l.Shares, weighting))
+		}
+		out.Write(row)
+	}
+	out.Flush()
+	return out.Error()
+}
+
+// writeReposCSV writes a row per repo with its languages as name=bytes pairs
+// separated by ";", largest first.
+func writeReposCSV(w io.Writer, header exportHeader, repos eachRepo) error {
+	writeCSVHeader(w, header)
+	out := csv.NewWriter(w)
+	out.Write([]string{"id", "full_name", "owner", "owner_type", "fork", "archived", "stars", "size", "license", "created_at", "pushed_at", "fetched_at", "bytes", "languages"})
+	err := repos(func(r exportRepo) error {
+		return out.Write([]string{
+			strconv.FormatInt(r.ID, 10),
+			r.FullName,
+			r.Owner,
+			r.OwnerType,
+			strconv.FormatBool(r.Fork),
+			strconv.FormatBool(r.Archived),
+			strconv.Itoa(r.Stars),
+			strconv.Itoa(r.Size),
+			r.License,
+			exportTime(r.CreatedAt),
+			exportTime(r.PushedAt),
+			exportTime(r.FetchedAt),
+			strconv.Itoa(r.Bytes),
+			languageList(r.Languages),
+		})
+	})
+	out.Flush()
+	if err != nil {
+		return err
+	}
+	return out.Error()
+}
+
+func exportTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func languageList(languages map[string]int) string {
+	names := make([]string, 0, len(languages))
+	for name := range languages {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if languages[names[i]] != languages[names[j]] {
+			return languages[names[i]] > languages[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		pairs[i] = fmt.Sprintf("%s=%d", name, languages[name])
+	}
+	return strings.Join(pairs, ";")
+}
+
+// JSON Lines exports start with the header and tell every line apart by its
+// type: header, language or repo.
+type jsonHeader struct {
+	Type string `json:"type"`
+	exportHeader
+}
+
+type jsonLanguage struct {
+	Type string `json:"type"`
+	exportLanguage
+}
+
+type jsonRepo struct {
+	Type string `json:"type"`
+	exportRepo
+}
+
+func writeLanguagesJSONL(w io.Writer, header exportHeader, languages []exportLanguage) error {
+	encoder := json.NewEncoder(w)
+	if err := encoder.Encode(jsonHeader{"header", header}); err != nil {
+		return err
+	}
+	for _, l := range languages {
+		if err := encoder.Encode(jsonLanguage{"language", l}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeReposJSONL(w io.Writer, header exportHeader, repos eachRepo) error {
+	encoder := json.NewEncoder(w)
+	if err := encoder.Encode(jsonHeader{"header", header}); err != nil {
+		return err
+	}
+	return repos(func(r exportRepo) error {
+		return encoder.Encode(jsonRepo{"repo", r})
+	})
+}
+
+// writeMarkdown writes the header and the top languages as Markdown tables.
+func writeMarkdown(w io.Writer, header exportHeader, languages []exportLanguage, top int) error {
+	weighting := stats.Weighting(header.Weighting)
+	fmt.Fprintf(w, "# GitHub language statistics\n\n")
+	fmt.Fprintf(w, "| Parameter | Value |\n| --- | --- |\n")
+	for _, field := range header.fields() {
+		fmt.Fprintf(w, "| %s | %s |\n", field[0], markdownEscape(field[1]))
+	}
+	fmt.Fprintf(w, "\n| Language | Bytes | Share (%s) | Repos | Repo share |\n| --- | ---: | ---: | ---: | ---: |\n", weighting)
+	for _, l := range topExportLanguages(languages, top) {
+		_, err := fmt.Fprintf(w, "| %s | %d | %.1f%% | %d | %.1f%% |\n", markdownEscape(l.Language), l.Bytes, l.Shares[weighting]*100, l.Repos, l.RepoShare*100)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var markdownEscaper = strings.NewReplacer(`\`, `\\`, "|", `\|`, "*", `\*`, "_", `\_`)
+
+func markdownEscape(s string) string {
+	return markdownEscaper.Replace(s)
+}
+
+func topExportLanguages(languages []exportLanguage, top int) []exportLanguage {
+	if top > 0 && len(languages) > top {
+		return languages[:top]
+	}
+	return languages
+}
+
+var htmlReport = template.Must(template.New("report").Funcs(template.FuncMap{
+	"percent": func(share float64) string { return fmt.Sprintf("%.1f%%", share*100) },
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>GitHub language statistics</title>
+</head>
+<body>
+<h1>GitHub language statistics</h1>
+<table>
+<tr><th>Parameter</th><th>Value</th></tr>
+{{range .Fields}}<tr><td>{{index . 0}}</td><td>{{index . 1}}</td></tr>
+{{end}}</table>
+<table>
+<tr><th>Language</th><th>Bytes</th><th>Share ({{.Weighting}})</th><th>Repos</th><th>Repo share</th></tr>
+{{range .Languages}}<tr><td>{{.Language}}</td><td>{{.Bytes}}</td><td>{{percent (index .Shares $.Weighting)}}</td><td>{{.Repos}}</td><td>{{percent .RepoShare}}</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+// writeHTML writes the same tables as writeMarkdown as an HTML page.
+func writeHTML(w io.Writer, header exportHeader, languages []exportLanguage, top int) error {
+	return htmlReport.Execute(w, struct {
+		Fields    [][2]string
+		Weighting stats.Weighting
+		Languages []exportLanguage
+	}{header.fields(), stats.Weighting(header.Weighting), topExportLanguages(languages, top)})
+}
+
+// exporter writes the files of an export to Dir.
+type exporter struct {
+	Dir     string
+	Formats []string
+	Top     int
+}
+
+// write writes every format of the export. repos is nil when there are no
+// repo records to export.
+func (e exporter) write(header exportHeader, languages []exportLanguage, repos eachRepo) ([]string, error) {
+	if err := os.MkdirAll(e.Dir, 0755); err != nil {
+		return nil, err
+	}
+
+	var written []string
+	create := func(name string, write func(io.Writer) error) error {
+		path := filepath.Join(e.Dir, name)
+		f, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		if err := write(f); err != nil {
+			f.Close()
+			return err
+		}
+		written = append(written, path)
+		return f.Close()
+	}
+
+	for _, format := range e.Formats {
+		var err error
+		switch format {
+		case "csv":
+			err = create("languages.csv", func(w io.Writer) error { return writeLanguagesCSV(w, header, languages) })
+			if err == nil && repos != nil {
+				err = create("repos.csv", func(w io.Writer) error { return writeReposCSV(w, header, repos) })
+			}
+		case "jsonl":
+			err = create("languages.jsonl", func(w io.Writer) error { return writeLanguagesJSONL(w, header, languages) })
+			if err == nil && repos != nil {
+				err = create("repos.jsonl", func(w io.Writer) error { return writeReposJSONL(w, header, repos) })
+			}
+		case "md":
+			err = create("report.md", func(w io.Writer) error { return writeMarkdown(w, header, languages, e.Top) })
+		case "html":
+			err = create("report.html", func(w io.Writer) error { return writeHTML(w, header, languages, e.Top) })
+		default:
+			err = fmt.Errorf("unknown export format %q, want csv, jsonl, md or html", format)
+		}
+		if err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
+
+// filteredUndetailed is why a stored repo without details is left out when
+// the filter needs them.
+const filteredUndetailed = "undetailed"
+
+// exportReject is why a stored repo is left out of an export. Unlike a crawl,
+// which fetches the details a rule needs, an export can only leave out the
+// repos stored without them.
+func exportReject(filter crawler.Filter, stored storage.RepoLanguages) string {
+	repo := stored.ToRepo()
+	if reason := filter.Reject(repo); reason != "" {
+		return reason
+	}
+	if filter.NeedsDetails() && !repo.Detailed() {
+		return filteredUndetailed
+	}
+	return ""
+}
+
+// exportStore aggregates the stored repos that pass filter into aggregator
+// and returns the repos to export, which reads the store again so the
+// records never need to fit in memory.
+func exportStore(store *storage.Store, filter crawler.Filter, aggregator *Aggregator, header *exportHeader) (eachRepo, error) {
+	err := store.EachRepo(func(repo storage.RepoLanguages) error {
+		if reason := exportReject(filter, repo); reason != "" {
+			header.Filtered++
+			if reason == filteredUndetailed {
+				header.Undetailed++
+			}
+			return nil
+		}
+		header.cover(repo)
+		aggregator.Add(Data{Repo: &repo})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	header.Repos = aggregator.Snapshot().Repos
+
+	return func(fn func(exportRepo) error) error {
+		return store.EachRepo(func(repo storage.RepoLanguages) error {
+			if exportReject(filter, repo) != "" {
+				return nil
+			}
+			repo.Languages = aggregator.normalize(repo.Languages)
+			bytes := 0
+			for _, b := range repo.Languages {
+				bytes += b
+			}
+			return fn(exportRepo{RepoLanguages: repo, Bytes: bytes})
+		})
+	}, nil
+}
+
+// checkpointHeader records in header how the crawl of checkpoint was set up
+// and how far it got. The checkpoint keeps languages as fetched, so unless
+// the export picks its own types or grouping, the crawl's are applied to them
+// through aggregator.
+func checkpointHeader(header *exportHeader, checkpoint storage.Checkpoint, aggregator *Aggregator) error {
+	header.Filters = append([]string{}, checkpoint.Filters...)
+	header.Processed = checkpoint.Processed
+	if !checkpoint.UpdatedAt.IsZero() {
+		updatedAt := checkpoint.UpdatedAt
+		header.FetchedTo = &updatedAt
+	}
+
+	if header.Types != "all" || header.Group || checkpoint.Types == "" {
+		return nil
+	}
+	types, err := catalog.ParseTypes(checkpoint.Types)
+	if err != nil {
+		return fmt.Errorf("checkpoint: %v", err)
+	}
+	mode := catalog.Mode{Types: types, Group: checkpoint.Group}
+	if len(mode.Types) > 0 || mode.Group {
+		aggregator.Normalize = func(languages map[string]int) map[string]int {
+			return catalog.Default().Apply(mode, languages)
+		}
+	}
+	header.Types, header.Group = typesList(mode.Types), mode.Group
+	return nil
+}
+
+// runExport exports the repos stored in MongoDB when mongo is set, filtered
+// and counted anew, and the totals of the checkpoint otherwise.
+func runExport(e exporter, header exportHeader, mongo, database, checkpointPath string, filter crawler.Filter, aggregator *Aggregator) int {
+	var repos eachRepo
+	if mongo != "" {
+		store, err := storage.Dial(mongo, database)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitFailed
+		}
+		defer store.Close()
+		header.Source = "mongodb database " + database
+		if repos, err = exportStore(store, filter, aggregator, &header); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitFailed
+		}
+	} else {
+		if header.Weighting != string(stats.Raw) {
+			fmt.Fprintf(os.Stderr, "-weighting %s needs the repos kept with -mongo, a checkpoint only has raw bytes\n", header.Weighting)
+			return exitUsage
+		}
+		checkpoint, found, err := storage.FileCheckpointer{Path: checkpointPath}.Load()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitFailed
+		}
+		if !found {
+			fmt.Fprintf(os.Stderr, "no checkpoint at %s to export, crawl first or export from -mongo\n", checkpointPath)
+			return exitFailed
+		}
+		if len(header.Filters) > 0 && strings.Join(header.Filters, " ") != strings.Join(checkpoint.Filters, " ") {
+			fmt.Fprintln(os.Stderr, "filters need the repos kept with -mongo, exporting the checkpoint as it was crawled")
+		}
+		if err := checkpointHeader(&header, checkpoint, aggregator); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitFailed
+		}
+		header.Source = "checkpoint " + checkpointPath
+		aggregator.Seed(checkpoint.Languages, nil)
+	}
+
+	written, err := e.write(header, exportLanguages(aggregator.Snapshot()), repos)
+	for _, path := range written {
+		fmt.Println(path)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitFailed
+	}
+	return exitDone
+}
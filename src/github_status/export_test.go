@@ -0,0 +1,188 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github_status/crawler"
+	"github_status/stats"
+	"github_status/storage"
+)
+
+var testHeader = exportHeader{
+	Schema:      exportSchema,
+	GeneratedAt: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+	Source:      "checkpoint test.json",
+	Filters:     []string{"-skip-forks"},
+	Types:       "all",
+	Weighting:   "vote",
+	WeightCap:   stats.DefaultCap,
+}
+
+func testExportRepos(fn func(exportRepo) error) error {
+	repos := []exportRepo{
+		{RepoLanguages: storage.RepoLanguages{ID: 1, FullName: "a/one", Owner: "a", Stars: 3, CreatedAt: time.Date(2012, 1, 2, 0, 0, 0, 0, time.UTC), Languages: map[string]int{"Go": 30, "C": 70}}, Bytes: 100},
+		{RepoLanguages: storage.RepoLanguages{ID: 2, FullName: "b/two, \"quoted\"", Owner: "b", Languages: map[string]int{"Go": 10}}, Bytes: 10},
+	}
+	for _, r := range repos {
+		if err := fn(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func testExportLanguages() []exportLanguage {
+	aggregator := NewAggregator()
+	testExportRepos(func(r exportRepo) error {
+		aggregator.Add(Data{Repo: &r.RepoLanguages})
+		return nil
+	})
+	return exportLanguages(aggregator.Snapshot())
+}
+
+func TestExportLanguages_lists_shares_under_every_weighting(t *testing.T) {
+	languages := testExportLanguages()
+
+	assert.Len(t, languages, 2)
+	assert.Equal(t, "C", languages[0].Language)
+	assert.InDelta(t, 70.0/110, languages[0].ByteShare, 1e-9)
+	assert.InDelta(t, 0.5, languages[0].RepoShare, 1e-9)
+	assert.InDelta(t, 0.35, languages[0].Shares[stats.Vote], 1e-9)
+	assert.InDelta(t, 0.5, languages[0].Shares[stats.Primary], 1e-9)
+	assert.InDelta(t, 1.0, languages[1].RepoShare, 1e-9)
+}
+
+func TestExportLanguages_only_has_raw_shares_for_a_checkpoint(t *testing.T) {
+	aggregator := NewAggregator()
+	aggregator.Seed(map[string]int{"Go": 30, "C": 10}, nil)
+	languages := exportLanguages(aggregator.Snapshot())
+
+	assert.Equal(t, map[stats.Weighting]float64{stats.Raw: 0.75}, languages[0].Shares)
+
+	var out bytes.Buffer
+	assert.Nil(t, writeLanguagesCSV(&out, testHeader, languages))
+	assert.Contains(t, out.String(), "\nGo,30,0.750000,0,0.000000,0.750000,,,,\n")
+}
+
+func TestRunExport_rejects_a_weighting_a_checkpoint_does_not_have(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkpoint.json")
+	assert.Nil(t, storage.FileCheckpointer{Path: path}.Save(storage.Checkpoint{Languages: map[string]int{"Go": 1}}))
+	dir := filepath.Join(t.TempDir(), "out")
+
+	code := runExport(exporter{Dir: dir, Formats: exportFormats}, testHeader, "", "", path, crawler.Filter{}, NewAggregator())
+
+	assert.Equal(t, exitUsage, code)
+	_, err := os.Stat(dir)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestExportReject_leaves_out_repos_without_the_details_a_filter_needs(t *testing.T) {
+	filter := crawler.Filter{MinStars: 10}
+	detailed := storage.RepoLanguages{Stars: 20, CreatedAt: time.Date(2012, 1, 2, 0, 0, 0, 0, time.UTC)}
+
+	assert.Equal(t, "", exportReject(filter, detailed))
+	assert.Equal(t, crawler.FilteredStars, exportReject(filter, storage.RepoLanguages{Stars: 5, CreatedAt: detailed.CreatedAt}))
+	assert.Equal(t, filteredUndetailed, exportReject(filter, storage.RepoLanguages{Stars: 20}))
+	assert.Equal(t, "", exportReject(crawler.Filter{SkipForks: true}, storage.RepoLanguages{}))
+}
+
+func TestCheckpointHeader_reports_how_the_crawl_was_set_up(t *testing.T) {
+	updatedAt := time.Date(2024, 4, 30, 8, 0, 0, 0, time.UTC)
+	checkpoint := storage.Checkpoint{Processed: 300, UpdatedAt: updatedAt, Filters: []string{"-skip-forks"}, Types: "all", Group: true}
+	header := exportHeader{Filters: []string{}, Types: "all"}
+	aggregator := NewAggregator()
+
+	assert.Nil(t, checkpointHeader(&header, checkpoint, aggregator))
+	aggregator.Seed(map[string]int{"TypeScript": 100, "TSX": 20}, nil)
+
+	assert.Equal(t, []string{"-skip-forks"}, header.Filters)
+	assert.Equal(t, 300, header.Processed)
+	assert.Equal(t, &updatedAt, header.FetchedTo)
+	assert.Nil(t, header.FetchedFrom)
+	assert.True(t, header.Group)
+	assert.Equal(t, map[string]int{"TypeScript": 120}, aggregator.Snapshot().Languages)
+}
+
+func TestWriteLanguagesCSV_starts_with_the_header_as_comments(t *testing.T) {
+	var out bytes.Buffer
+	assert.Nil(t, writeLanguagesCSV(&out, testHeader, testExportLanguages()))
+	lines := strings.Split(strings.TrimRight(out.String(), "\n"), "\n")
+
+	assert.Equal(t, "# schema: 1", lines[0])
+	assert.Contains(t, lines, "# filters: -skip-forks")
+	assert.Contains(t, lines, "# created_from: ")
+	columns := lines[len(testHeader.fields())]
+	assert.Equal(t, "language,bytes,byte_share,repos,repo_share,share_raw,share_log,share_vote,share_primary,share_capped", columns)
+	assert.True(t, strings.HasPrefix(lines[len(lines)-1], "Go,40,0.363636,2,1.000000,0.363636,"))
+}
+
+func TestWriteReposCSV_quotes_fields_and_lists_languages_by_bytes(t *testing.T) {
+	var out bytes.Buffer
+	assert.Nil(t, writeReposCSV(&out, testHeader, testExportRepos))
+	lines := strings.Split(strings.TrimRight(out.String(), "\n"), "\n")
+	rows := lines[len(testHeader.fields())+1:]
+
+	assert.Len(t, rows, 2)
+	assert.Equal(t, "1,a/one,a,,false,false,3,0,,2012-01-02T00:00:00Z,,,100,C=70;Go=30", rows[0])
+	assert.Equal(t, `2,"b/two, ""quoted""",b,,false,false,0,0,,,,,10,Go=10`, rows[1])
+}
+
+func TestWriteJSONL_tells_lines_apart_by_type(t *testing.T) {
+	var out bytes.Buffer
+	assert.Nil(t, writeReposJSONL(&out, testHeader, testExportRepos))
+	lines := strings.Split(strings.TrimRight(out.String(), "\n"), "\n")
+
+	assert.Len(t, lines, 3)
+	var header map[string]interface{}
+	assert.Nil(t, json.Unmarshal([]byte(lines[0]), &header))
+	assert.Equal(t, "header", header["type"])
+	assert.Equal(t, 1.0, header["schema"])
+	assert.Nil(t, header["created_from"])
+
+	var repo map[string]interface{}
+	assert.Nil(t, json.Unmarshal([]byte(lines[1]), &repo))
+	assert.Equal(t, "repo", repo["type"])
+	assert.Equal(t, "a/one", repo["full_name"])
+	assert.Equal(t, 100.0, repo["bytes"])
+}
+
+func TestWriteMarkdown_escapes_table_cells(t *testing.T) {
+	languages := []exportLanguage{{Language: "C|Pipes", Bytes: 5, Shares: map[stats.Weighting]float64{stats.Vote: 0.25}}}
+
+	var out bytes.Buffer
+	assert.Nil(t, writeMarkdown(&out, testHeader, languages, 10))
+
+	assert.Contains(t, out.String(), "| source | checkpoint test.json |\n")
+	assert.Contains(t, out.String(), "| Language | Bytes | Share (vote) | Repos | Repo share |\n")
+	assert.Contains(t, out.String(), `| C\|Pipes | 5 | 25.0% | 0 | 0.0% |`)
+}
+
+func TestExporter_write_writes_every_format(t *testing.T) {
+	dir := t.TempDir()
+	e := exporter{Dir: dir, Formats: exportFormats, Top: 1}
+
+	written, err := e.write(testHeader, testExportLanguages(), testExportRepos)
+
+	assert.Nil(t, err)
+	names := make([]string, len(written))
+	for i, path := range written {
+		names[i] = filepath.Base(path)
+	}
+	assert.Equal(t, []string{"languages.csv", "repos.csv", "languages.jsonl", "repos.jsonl", "report.md", "report.html"}, names)
+	html, _ := os.ReadFile(filepath.Join(dir, "report.html"))
+	assert.Contains(t, string(html), "<td>C</td><td>70</td><td>35.0%</td>")
+	assert.NotContains(t, string(html), "<td>Go</td>")
+}
+
+func TestExporter_write_rejects_unknown_formats(t *testing.T) {
+	_, err := exporter{Dir: t.TempDir(), Formats: []string{"xml"}}.write(testHeader, nil, nil)
+
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,89 @@
+package github
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// CacheEntry is what is needed to make a conditional request for a URL and
+// to replay its body when GitHub answers 304 Not Modified, which does not
+// count against the rate limit.
+type CacheEntry struct {
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+	Link         string `json:"link,omitempty"`
+	Body         []byte `json:"body"`
+}
+
+type Cache interface {
+	Get(url string) (CacheEntry, bool)
+	Set(url string, entry CacheEntry) error
+}
+
+type MemoryCache struct {
+	mutex   sync.RWMutex
+	entries map[string]CacheEntry
+}
+
+func NewMemoryCache() *MemoryCache {
+	return &MemoryCache{entries: make(map[string]CacheEntry)}
+}
+
+func (m *MemoryCache) Get(url string) (CacheEntry, bool) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	entry, ok := m.entries[url]
+	return entry, ok
+}
+
+func (m *MemoryCache) Set(url string, entry CacheEntry) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.entries[url] = entry
+	return nil
+}
+
+// DiskCache keeps one JSON file per URL in Dir so the cache survives
+// restarts.
+type DiskCache struct {
+	Dir string
+}
+
+func (d DiskCache) path(url string) string {
+	sum := sha1.Sum([]byte(url))
+	name := hex.EncodeToString(sum[:])
+	return filepath.Join(d.Dir, name[:2], name+".json")
+}
+
+func (d DiskCache) Get(url string) (CacheEntry, bool) {
+	var entry CacheEntry
+	data, err := ioutil.ReadFile(d.path(url))
+	if err != nil {
+		return entry, false
+	}
+	return entry, json.Unmarshal(data, &entry) == nil
+}
+
+func (d DiskCache) Set(url string, entry CacheEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	path := d.path(url)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,74 @@
+package github
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryCache_returns_what_was_set(t *testing.T) {
+	cache := NewMemoryCache()
+	cache.Set("https://api.github.com/repositories", CacheEntry{ETag: `"abc"`, Body: []byte("[]")})
+
+	entry, found := cache.Get("https://api.github.com/repositories")
+	_, missing := cache.Get("https://api.github.com/other")
+
+	assert.True(t, found)
+	assert.False(t, missing)
+	assert.Equal(t, `"abc"`, entry.ETag)
+}
+
+func TestDiskCache_round_trips_entries(t *testing.T) {
+	cache := DiskCache{Dir: t.TempDir()}
+	entry := CacheEntry{ETag: `"abc"`, LastModified: "Sat, 30 Nov 2013 02:40:57 GMT", Link: `<x>; rel="next"`, Body: []byte(`{"Go":1}`)}
+
+	assert.Nil(t, cache.Set("https://api.github.com/repos/a/b/languages", entry))
+	loaded, found := cache.Get("https://api.github.com/repos/a/b/languages")
+
+	assert.True(t, found)
+	assert.Equal(t, entry, loaded)
+}
+
+func TestGet_replays_the_cached_body_on_not_modified(t *testing.T) {
+	requests := 0
+	fakeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Link", `<https://api.github.com/repositories?since=369>; rel="next"`)
+		fmt.Fprint(w, `{"Go": 10}`)
+	}))
+	defer fakeServer.Close()
+
+	client := fakeClient(fakeServer)
+	client.Cache = NewMemoryCache()
+
+	first := map[string]int{}
+	client.get("repos/a/b/languages", nil, &first)
+	second := map[string]int{}
+	header, err := client.get("repos/a/b/languages", nil, &second)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 2, requests)
+	assert.Equal(t, map[string]int{"Go": 10}, second)
+	assert.Equal(t, "369", header.Next.Query().Get("since"))
+}
+
+func TestClient_refunds_requests_answered_with_not_modified(t *testing.T) {
+	fakeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotModified)
+	}))
+	defer fakeServer.Close()
+
+	pool := NewTokenPool("a")
+	req, _ := http.NewRequest("GET", fakeServer.URL, nil)
+	fakeClient(fakeServer).do(pool, req)
+
+	assert.Equal(t, DefaultRateLimit, pool.Pick().RateLimitRemaining)
+}
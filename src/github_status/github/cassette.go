@@ -0,0 +1,197 @@
+package github
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+)
+
+type CassetteMode int
+
+const (
+	Record CassetteMode = iota
+	Replay
+)
+
+// ScrubbedHeaders are never written to a cassette.
+var ScrubbedHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+
+const scrubbed = "REDACTED"
+
+type recordedRequest struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header"`
+	Body   string      `json:"body,omitempty"`
+}
+
+type recordedResponse struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header"`
+	Body       string      `json:"body"`
+}
+
+type interaction struct {
+	Request  recordedRequest  `json:"request"`
+	Response recordedResponse `json:"response"`
+}
+
+// Cassette is an http.RoundTripper that records every request and response,
+// rate limit and Link headers included, as a JSON file in Dir, or replays
+// those files without touching the network. A request made several times is
+// recorded once per occurrence and replayed in the same order; past the last
+// recorded occurrence the last one is replayed again.
+type Cassette struct {
+	Dir       string
+	Mode      CassetteMode
+	Transport http.RoundTripper
+
+	mutex       sync.Mutex
+	occurrences map[string]int
+}
+
+func NewCassette(dir string, mode CassetteMode, transport http.RoundTripper) *Cassette {
+	return &Cassette{Dir: dir, Mode: mode, Transport: transport}
+}
+
+func (c *Cassette) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		if body, err = ioutil.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+
+	key := cassetteKey(req, body)
+	c.mutex.Lock()
+	if c.occurrences == nil {
+		c.occurrences = make(map[string]int)
+	}
+	occurrence := c.occurrences[key]
+	c.occurrences[key]++
+	c.mutex.Unlock()
+
+	if c.Mode == Replay {
+		return c.replay(req, key, occurrence)
+	}
+	return c.record(req, body, key, occurrence)
+}
+
+func (c *Cassette) record(req *http.Request, body []byte, key string, occurrence int) (*http.Response, error) {
+	transport := c.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(respBody))
+
+	recorded := interaction{
+		Request: recordedRequest{
+			Method: req.Method,
+			URL:    scrubURL(req),
+			Header: scrubHeader(req.Header),
+			Body:   string(body),
+		},
+		Response: recordedResponse{
+			StatusCode: resp.StatusCode,
+			Header:     scrubHeader(resp.Header),
+			Body:       string(respBody),
+		},
+	}
+	data, err := json.MarshalIndent(recorded, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(c.Dir, 0755); err != nil {
+		return nil, err
+	}
+	return resp, ioutil.WriteFile(c.path(key, occurrence), data, 0644)
+}
+
+func (c *Cassette) replay(req *http.Request, key string, occurrence int) (*http.Response, error) {
+	var data []byte
+	var err error
+	for ; occurrence >= 0; occurrence-- {
+		if data, err = ioutil.ReadFile(c.path(key, occurrence)); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		// Replaying again would not find it either.
+		return nil, &Error{URL: scrubURL(req), Err: fmt.Errorf("no recorded response for %s in %s", req.Method, c.Dir)}
+	}
+
+	var recorded interaction
+	if err := json.Unmarshal(data, &recorded); err != nil {
+		return nil, err
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", recorded.Response.StatusCode, http.StatusText(recorded.Response.StatusCode)),
+		StatusCode:    recorded.Response.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        recorded.Response.Header,
+		Body:          ioutil.NopCloser(bytes.NewReader([]byte(recorded.Response.Body))),
+		ContentLength: int64(len(recorded.Response.Body)),
+		Request:       req,
+	}, nil
+}
+
+var unsafePathCharacters = regexp.MustCompile(`[^A-Za-z0-9]+`)
+
+// path names the file after the request so a cassette directory can be
+// browsed, with a hash to keep distinct requests apart.
+func (c *Cassette) path(key string, occurrence int) string {
+	sum := sha1.Sum([]byte(key))
+	name := unsafePathCharacters.ReplaceAllString(key, "_")
+	if len(name) > 80 {
+		name = name[:80]
+	}
+	return filepath.Join(c.Dir, fmt.Sprintf("%s-%s-%d.json", name, hex.EncodeToString(sum[:4]), occurrence))
+}
+
+func cassetteKey(req *http.Request, body []byte) string {
+	key := req.Method + " " + req.URL.Host + req.URL.RequestURI()
+	if len(body) > 0 {
+		sum := sha1.Sum(body)
+		key += " " + hex.EncodeToString(sum[:])
+	}
+	return key
+}
+
+func scrubURL(req *http.Request) string {
+	u := *req.URL
+	u.User = nil
+	return u.String()
+}
+
+func scrubHeader(header http.Header) http.Header {
+	clean := header.Clone()
+	for _, name := range ScrubbedHeaders {
+		if clean.Get(name) != "" {
+			clean.Set(name, scrubbed)
+		}
+	}
+	return clean
+}
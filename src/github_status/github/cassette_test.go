@@ -0,0 +1,90 @@
+package github
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCassette_replays_a_recorded_page_without_the_network(t *testing.T) {
+	client := NewClient(NewTokenPool("secret"))
+	client.HTTPClient.Transport = NewCassette("testdata/cassette", Replay, nil)
+
+	repos, header, err := client.GetRepos("repositories?since=369")
+
+	assert.Nil(t, err)
+	assert.Len(t, repos, 3)
+	assert.Equal(t, 4987, header.RateLimitRemaining)
+	assert.Equal(t, time.Unix(1385779257, 0), header.RateLimitReset)
+	assert.Equal(t, "https://api.github.com/repositories?since=27", header.Next.String())
+}
+
+func TestCassette_records_what_it_replays(t *testing.T) {
+	requests := 0
+	fakeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("X-RateLimit-Remaining", fmt.Sprint(5000-requests))
+		w.Header().Set("Link", `<https://api.github.com/repositories?since=27>; rel="next"`)
+		fmt.Fprint(w, `{"Go": 10}`)
+	}))
+	dir := t.TempDir()
+
+	client := fakeClient(fakeServer)
+	client.Tokens = NewTokenPool("secret")
+	client.HTTPClient.Transport = NewCassette(dir, Record, fakeServer.Client().Transport)
+	client.GetLanguages("a/b")
+	client.GetLanguages("a/b")
+	fakeServer.Close()
+
+	client.HTTPClient.Transport = NewCassette(dir, Replay, nil)
+	first, firstHeader, err := client.GetLanguages("a/b")
+	_, secondHeader, _ := client.GetLanguages("a/b")
+	_, thirdHeader, _ := client.GetLanguages("a/b")
+
+	assert.Nil(t, err)
+	assert.Equal(t, 2, requests)
+	assert.Equal(t, map[string]int{"Go": 10}, first)
+	assert.Equal(t, 4999, firstHeader.RateLimitRemaining)
+	assert.Equal(t, 4998, secondHeader.RateLimitRemaining)
+	assert.Equal(t, 4998, thirdHeader.RateLimitRemaining)
+	assert.Equal(t, "27", firstHeader.Next.Query().Get("since"))
+}
+
+func TestCassette_scrubs_secrets_from_recordings(t *testing.T) {
+	fakeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "cookie-secret"})
+		fmt.Fprint(w, "{}")
+	}))
+	defer fakeServer.Close()
+	dir := t.TempDir()
+
+	client := fakeClient(fakeServer)
+	client.Tokens = NewTokenPool("token-secret")
+	client.HTTPClient.Transport = NewCassette(dir, Record, fakeServer.Client().Transport)
+	client.GetLanguages("a/b")
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	assert.Len(t, files, 1)
+	recorded, _ := ioutil.ReadFile(files[0])
+	assert.NotContains(t, string(recorded), "token-secret")
+	assert.NotContains(t, string(recorded), "cookie-secret")
+	assert.Contains(t, string(recorded), scrubbed)
+}
+
+func TestCassette_fails_replaying_an_unrecorded_request(t *testing.T) {
+	client := NewClient(nil)
+	client.HTTPClient.Transport = NewCassette(t.TempDir(), Replay, nil)
+	start := time.Now()
+
+	_, _, err := client.GetLanguages("a/b")
+
+	assert.True(t, IsPermanent(err))
+	assert.Contains(t, err.Error(), "no recorded response for GET")
+	assert.True(t, time.Since(start) < time.Second)
+}
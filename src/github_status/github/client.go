@@ -0,0 +1,230 @@
+package github
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const DefaultBaseURL = "https://api.github.com/"
+
+const DefaultUserAgent = "github_stats"
+
+// Client talks to the GitHub API at BaseURL, which is api.github.com or a
+// GitHub Enterprise server's /api/v3/. Every request is signed from Tokens,
+// retried with Retry and, when Cache is set, made conditional. Closing Stop
+// ends every wait for a rate limit or a retry and fails requests not sent
+// yet with ErrStopped.
+type Client struct {
+	BaseURL    *url.URL
+	GraphQLURL *url.URL
+	HTTPClient *http.Client
+	UserAgent  string
+	Tokens     *TokenPool
+	Cache      Cache
+	Retry      RetryPolicy
+	Metrics    *Metrics
+	Stop       <-chan struct{}
+}
+
+func NewClient(tokens *TokenPool) *Client {
+	client, _ := NewEnterpriseClient(DefaultBaseURL, tokens)
+	return client
+}
+
+// NewEnterpriseClient returns a client for the API at baseURL, for instance
+// https://github.example.com/api/v3/. GraphQL is served from the sibling
+// /api/graphql on Enterprise and from /graphql on api.github.com.
+func NewEnterpriseClient(baseURL string, tokens *TokenPool) (*Client, error) {
+	if !strings.HasSuffix(baseURL, "/") {
+		baseURL += "/"
+	}
+	base, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, err
+	}
+
+	graphql := base.ResolveReference(&url.URL{Path: "graphql"})
+	if strings.HasSuffix(base.Path, "/api/v3/") {
+		graphql = base.ResolveReference(&url.URL{Path: "../graphql"})
+	}
+
+	return &Client{
+		BaseURL:    base,
+		GraphQLURL: graphql,
+		HTTPClient: &http.Client{Timeout: 30 * time.Second},
+		UserAgent:  DefaultUserAgent,
+		Tokens:     tokens,
+		Retry:      DefaultRetryPolicy,
+		Metrics:    NewMetrics(),
+	}, nil
+}
+
+// URL resolves path, such as "repositories?since=369", against BaseURL.
+// Absolute URLs, like the ones in Link headers, are returned unchanged.
+func (c *Client) URL(path string) string {
+	ref, err := url.Parse(path)
+	if err != nil {
+		return path
+	}
+	return c.BaseURL.ResolveReference(ref).String()
+}
+
+func (c *Client) get(path string, tokens *TokenPool, v interface{}) (GitHubHeader, error) {
+	return c.request("GET", c.URL(path), nil, tokens, v)
+}
+
+// request decodes the response to url into v, retrying transient failures.
+// With a Cache, GETs are made conditional and a 304 replays the cached body.
+func (c *Client) request(method string, url string, payload []byte, tokens *TokenPool, v interface{}) (GitHubHeader, error) {
+	var header GitHubHeader
+	cacheable := method == "GET" && c.Cache != nil
+	attempts := 0
+	err := c.Retry.Do(c.Stop, func() error {
+		req, err := http.NewRequest(method, url, bytes.NewReader(payload))
+		if err != nil {
+			return &Error{URL: url, Err: err}
+		}
+		if attempts++; attempts > 1 {
+			c.Metrics.retry(c.endpoint(req.URL))
+		}
+		req.Header.Set("Accept", "application/vnd.github+json")
+		if c.UserAgent != "" {
+			req.Header.Set("User-Agent", c.UserAgent)
+		}
+		if payload != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+
+		cached, found := CacheEntry{}, false
+		if cacheable {
+			cached, found = c.Cache.Get(url)
+		}
+		if found && cached.ETag != "" {
+			req.Header.Set("If-None-Match", cached.ETag)
+		}
+		if found && cached.LastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.LastModified)
+		}
+
+		resp, err := c.do(tokens, req)
+		if err == ErrStopped {
+			return err
+		}
+		if err != nil {
+			return transportError(url, err)
+		}
+		defer resp.Body.Close()
+
+		if found && resp.StatusCode == http.StatusNotModified {
+			if resp.Header.Get("Link") == "" && cached.Link != "" {
+				resp.Header.Set("Link", cached.Link)
+			}
+			header = ParseHeader(resp.Header)
+			return decode(url, cached.Body, v)
+		}
+
+		header = ParseHeader(resp.Header)
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			e := statusError(url, resp)
+			if e.SecondaryRateLimit {
+				tokens.Pause(e.RetryAfter)
+			}
+			return e
+		}
+
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return transportError(url, err)
+		}
+		if err := decode(url, body, v); err != nil {
+			return err
+		}
+
+		if cacheable && (resp.Header.Get("ETag") != "" || resp.Header.Get("Last-Modified") != "") {
+			c.Cache.Set(url, CacheEntry{
+				ETag:         resp.Header.Get("ETag"),
+				LastModified: resp.Header.Get("Last-Modified"),
+				Link:         resp.Header.Get("Link"),
+				Body:         body,
+			})
+		}
+		return nil
+	})
+	return header, err
+}
+
+// validator is a response that can decode fine and still report a failure,
+// like GraphQL does with a 200.
+type validator interface {
+	validate(url string) *Error
+}
+
+func decode(url string, body []byte, v interface{}) error {
+	if err := json.Unmarshal(body, v); err != nil {
+		return &Error{URL: url, StatusCode: http.StatusOK, Err: err}
+	}
+	if v, ok := v.(validator); ok {
+		if e := v.validate(url); e != nil {
+			return e
+		}
+	}
+	return nil
+}
+
+// do signs the request with the best token of tokens, waiting for a rate
+// limit reset when every token is exhausted, and fails over to the next token
+// when GitHub rejects the request because the budget ran out early.
+func (c *Client) do(tokens *TokenPool, req *http.Request) (*http.Response, error) {
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	for failovers := 0; ; {
+		select {
+		case <-c.Stop:
+			return nil, ErrStopped
+		default:
+		}
+		token, wait := tokens.Reserve()
+		if wait > 0 {
+			if sleep(c.Stop, wait) {
+				return nil, ErrStopped
+			}
+			continue
+		}
+		if token != nil {
+			token.Authorize(req)
+		}
+
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			c.Metrics.request(c.endpoint(req.URL), 0)
+			tokens.Update(token, GitHubHeader{})
+			return nil, err
+		}
+		c.Metrics.request(c.endpoint(req.URL), resp.StatusCode)
+
+		header := ParseHeader(resp.Header)
+		tokens.Update(token, header)
+		if resp.StatusCode == http.StatusNotModified {
+			tokens.refund(token)
+		}
+
+		if resp.StatusCode != http.StatusForbidden || header.RateLimitRemaining != 0 || failovers+1 >= tokens.Len() {
+			return resp, nil
+		}
+		failovers++
+		resp.Body.Close()
+		if req.GetBody != nil {
+			if req.Body, err = req.GetBody(); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
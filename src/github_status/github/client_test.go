@@ -0,0 +1,120 @@
+package github
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func fakeClient(fakeServer *httptest.Server) *Client {
+	client, _ := NewEnterpriseClient(fakeServer.URL, nil)
+	client.HTTPClient = fakeServer.Client()
+	return client
+}
+
+func TestNewClient_talks_to_api_github_com(t *testing.T) {
+	client := NewClient(nil)
+
+	assert.Equal(t, "https://api.github.com/repositories?since=1", client.URL("repositories?since=1"))
+	assert.Equal(t, "https://api.github.com/graphql", client.GraphQLURL.String())
+}
+
+func TestNewEnterpriseClient_resolves_paths_under_api_v3(t *testing.T) {
+	client, err := NewEnterpriseClient("https://github.example.com/api/v3", nil)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "https://github.example.com/api/v3/repos/a/b/languages", client.URL("repos/a/b/languages"))
+	assert.Equal(t, "https://github.example.com/api/graphql", client.GraphQLURL.String())
+}
+
+func TestClient_URL_keeps_absolute_links(t *testing.T) {
+	client := NewClient(nil)
+
+	assert.Equal(t, "https://example.com/repositories?since=9", client.URL("https://example.com/repositories?since=9"))
+}
+
+func TestClient_sends_the_token_and_user_agent(t *testing.T) {
+	var authorization, userAgent string
+	fakeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		userAgent = r.Header.Get("User-Agent")
+		fmt.Fprint(w, "{}")
+	}))
+	defer fakeServer.Close()
+
+	client := fakeClient(fakeServer)
+	client.Tokens = NewTokenPool("secret")
+	_, _, err := client.GetLanguages("a/b")
+
+	assert.Nil(t, err)
+	assert.Equal(t, "token secret", authorization)
+	assert.Equal(t, DefaultUserAgent, userAgent)
+}
+
+func TestClient_fails_over_when_a_token_is_exhausted(t *testing.T) {
+	reset := strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10)
+	fakeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Reset", reset)
+		if r.Header.Get("Authorization") == "token exhausted" {
+			w.Header().Set("X-RateLimit-Remaining", "0")
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Header().Set("X-RateLimit-Remaining", "4999")
+		fmt.Fprint(w, `{"Go": 1}`)
+	}))
+	defer fakeServer.Close()
+
+	client := fakeClient(fakeServer)
+	client.Tokens = NewTokenPool("exhausted", "fresh")
+	languages, _, err := client.GetLanguages("a/b")
+
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]int{"Go": 1}, languages)
+	assert.Equal(t, "fresh", client.Tokens.Pick().Value)
+}
+
+func TestClient_gives_up_on_requests_that_time_out(t *testing.T) {
+	defer func() { sleep = sleepUntil }()
+	sleep = func(<-chan struct{}, time.Duration) bool { return false }
+
+	fakeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(50 * time.Millisecond)
+	}))
+	defer fakeServer.Close()
+
+	client := fakeClient(fakeServer)
+	client.HTTPClient.Timeout = 10 * time.Millisecond
+	client.Retry.MaxAttempts = 2
+	_, _, err := client.GetLanguages("a/b")
+
+	assert.True(t, IsTransient(err))
+}
+
+func TestClient_stops_waiting_for_the_rate_limit_when_stopped(t *testing.T) {
+	requests := 0
+	fakeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		fmt.Fprint(w, "{}")
+	}))
+	defer fakeServer.Close()
+
+	stop := make(chan struct{})
+	client := fakeClient(fakeServer)
+	client.Stop = stop
+	client.Tokens = NewTokenPool("secret")
+	client.Tokens.Pause(time.Hour)
+	time.AfterFunc(10*time.Millisecond, func() { close(stop) })
+
+	start := time.Now()
+	_, _, err := client.GetLanguages("a/b")
+
+	assert.Equal(t, ErrStopped, err)
+	assert.True(t, time.Since(start) < time.Second)
+	assert.Equal(t, 0, requests)
+}
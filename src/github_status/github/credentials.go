@@ -0,0 +1,228 @@
+package github
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultRateLimit is assumed for a token until GitHub reports its real budget.
+const DefaultRateLimit = 5000
+
+const AnonymousRateLimit = 60
+
+// resourceLimits are the default budgets, authenticated and anonymous, of the
+// rate limit resources GitHub tracks separately from the core API.
+var resourceLimits = map[string][2]int{
+	"search":  {30, 10},
+	"graphql": {5000, 0},
+}
+
+type Token struct {
+	Value              string
+	RateLimit          int
+	RateLimitRemaining int
+	RateLimitUsed      int
+	RateLimitReset     time.Time
+
+	inFlight int
+	nextSlot time.Time
+}
+
+func (t *Token) Anonymous() bool {
+	return t.Value == ""
+}
+
+func (t *Token) Authorize(req *http.Request) {
+	if t.Anonymous() {
+		return
+	}
+	req.Header.Set("Authorization", fmt.Sprintf("token %s", t.Value))
+}
+
+// TokenPool hands out the token with the most remaining rate limit and keeps
+// each token's budget up to date from the headers of the responses it signs.
+// Every request made through the pool reserves one call from that budget, so
+// any number of concurrent callers never spend more than GitHub allows. A pool
+// without tokens makes anonymous requests against the anonymous budget.
+//
+// The pool is the crawler's rate limiter: see limiter.go for how requests are
+// paced over the rate limit window.
+type TokenPool struct {
+	// Burst is how many requests a token may run ahead of its even pace.
+	Burst int
+
+	mutex       sync.Mutex
+	tokens      []*Token
+	resource    string
+	parent      *TokenPool
+	resources   map[string]*TokenPool
+	pausedUntil time.Time
+}
+
+func NewTokenPool(values ...string) *TokenPool {
+	return newTokenPool(values, DefaultRateLimit, AnonymousRateLimit)
+}
+
+func newTokenPool(values []string, limit int, anonymousLimit int) *TokenPool {
+	pool := &TokenPool{}
+	seen := make(map[string]bool)
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if value == "" || seen[value] {
+			continue
+		}
+		seen[value] = true
+		pool.tokens = append(pool.tokens, &Token{Value: value, RateLimit: limit, RateLimitRemaining: limit})
+	}
+	if len(pool.tokens) == 0 {
+		pool.tokens = append(pool.tokens, &Token{RateLimit: anonymousLimit, RateLimitRemaining: anonymousLimit})
+	}
+	return pool
+}
+
+// Resource returns a pool of the same tokens that tracks the separate budget
+// GitHub keeps for a resource such as "search" or "graphql". The "core"
+// resource is the pool itself.
+func (p *TokenPool) Resource(name string) *TokenPool {
+	if p == nil {
+		return nil
+	}
+	root := p.root()
+	if name == "" || name == "core" {
+		return root
+	}
+	root.mutex.Lock()
+	defer root.mutex.Unlock()
+
+	if pool, ok := root.resources[name]; ok {
+		return pool
+	}
+
+	var values []string
+	for _, token := range root.tokens {
+		values = append(values, token.Value)
+	}
+	limits, ok := resourceLimits[name]
+	if !ok {
+		limits = [2]int{DefaultRateLimit, AnonymousRateLimit}
+	}
+
+	if root.resources == nil {
+		root.resources = make(map[string]*TokenPool)
+	}
+	pool := newTokenPool(values, limits[0], limits[1])
+	pool.resource = name
+	pool.parent = root
+	root.resources[name] = pool
+	return pool
+}
+
+// Resources returns the pools of every resource used so far by name,
+// including "core".
+func (p *TokenPool) Resources() map[string]*TokenPool {
+	if p == nil {
+		return nil
+	}
+	root := p.root()
+	root.mutex.Lock()
+	defer root.mutex.Unlock()
+
+	resources := map[string]*TokenPool{"core": root}
+	for name, pool := range root.resources {
+		resources[name] = pool
+	}
+	return resources
+}
+
+func (p *TokenPool) root() *TokenPool {
+	if p.parent != nil {
+		return p.parent
+	}
+	return p
+}
+
+// LoadTokenPool builds a pool from GITHUB_TOKEN, the comma separated
+// GITHUB_TOKENS and the file named by GITHUB_TOKENS_FILE (one token per line,
+// # starts a comment).
+func LoadTokenPool(getenv func(string) string) (*TokenPool, error) {
+	var values []string
+	values = append(values, getenv("GITHUB_TOKEN"))
+	values = append(values, strings.Split(getenv("GITHUB_TOKENS"), ",")...)
+
+	if path := getenv("GITHUB_TOKENS_FILE"); path != "" {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			line := scanner.Text()
+			if i := strings.Index(line, "#"); i >= 0 {
+				line = line[:i]
+			}
+			values = append(values, line)
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	return NewTokenPool(values...), nil
+}
+
+func (p *TokenPool) Len() int {
+	if p == nil {
+		return 0
+	}
+	return len(p.tokens)
+}
+
+func (p *TokenPool) Tokens() []Token {
+	if p == nil {
+		return nil
+	}
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	tokens := make([]Token, len(p.tokens))
+	for i, token := range p.tokens {
+		tokens[i] = *token
+	}
+	return tokens
+}
+
+func (p *TokenPool) Pick() *Token {
+	if p == nil {
+		return nil
+	}
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	return p.pick(time.Now())
+}
+
+func (p *TokenPool) pick(now time.Time) *Token {
+	var best *Token
+	for _, token := range p.tokens {
+		refresh(token, now)
+		if best == nil || token.RateLimitRemaining > best.RateLimitRemaining {
+			best = token
+		}
+	}
+	return best
+}
+
+// refresh replenishes a token whose rate limit window has ended.
+func refresh(token *Token, now time.Time) {
+	if !token.RateLimitReset.IsZero() && now.After(token.RateLimitReset) {
+		token.RateLimitRemaining = token.RateLimit - token.inFlight
+		token.RateLimitReset = time.Time{}
+	}
+}
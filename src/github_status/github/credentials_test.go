@@ -0,0 +1,106 @@
+package github
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadTokenPool_reads_tokens_from_the_environment_and_a_file(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tokens")
+	ioutil.WriteFile(path, []byte("# team tokens\nfile-token\n\nshared # duplicate\n"), 0600)
+
+	env := map[string]string{
+		"GITHUB_TOKEN":       "single",
+		"GITHUB_TOKENS":      "first, shared",
+		"GITHUB_TOKENS_FILE": path,
+	}
+	pool, err := LoadTokenPool(func(key string) string { return env[key] })
+
+	assert.Nil(t, err)
+	values := []string{}
+	for _, token := range pool.Tokens() {
+		values = append(values, token.Value)
+	}
+	assert.Equal(t, []string{"single", "first", "shared", "file-token"}, values)
+}
+
+func TestLoadTokenPool_returns_an_error_for_a_missing_file(t *testing.T) {
+	_, err := LoadTokenPool(func(key string) string {
+		if key == "GITHUB_TOKENS_FILE" {
+			return filepath.Join(os.TempDir(), "does-not-exist")
+		}
+		return ""
+	})
+
+	assert.NotNil(t, err)
+}
+
+func TestTokenPool_Pick_returns_the_token_with_the_most_remaining(t *testing.T) {
+	pool := NewTokenPool("a", "b")
+	tokens := pool.Tokens()
+	reset := time.Now().Add(time.Hour)
+
+	pool.Update(pool.Pick(), GitHubHeader{RateLimitRemaining: 10, RateLimitReset: reset})
+
+	assert.Equal(t, tokens[1].Value, pool.Pick().Value)
+}
+
+func TestTokenPool_WaitDuration_is_zero_while_any_token_has_budget(t *testing.T) {
+	pool := NewTokenPool("a", "b")
+	reset := time.Now().Add(time.Hour)
+	pool.Update(pool.Pick(), GitHubHeader{RateLimitRemaining: 0, RateLimitReset: reset})
+
+	assert.Equal(t, time.Duration(0), pool.WaitDuration())
+
+	pool.Update(pool.Pick(), GitHubHeader{RateLimitRemaining: 0, RateLimitReset: reset.Add(time.Hour)})
+
+	assert.True(t, pool.WaitDuration() > 59*time.Minute)
+	assert.True(t, pool.WaitDuration() <= time.Hour)
+}
+
+func TestNewTokenPool_without_tokens_uses_the_anonymous_budget(t *testing.T) {
+	token := NewTokenPool().Pick()
+
+	assert.True(t, token.Anonymous())
+	assert.Equal(t, AnonymousRateLimit, token.RateLimitRemaining)
+}
+
+func TestTokenPool_Reserve_never_hands_out_more_than_the_remaining_budget(t *testing.T) {
+	pool := NewTokenPool("a")
+	pool.Update(pool.Pick(), GitHubHeader{RateLimitRemaining: 2, RateLimitReset: time.Now().Add(time.Hour)})
+
+	first, _ := pool.Reserve()
+	second, _ := pool.Reserve()
+	third, wait := pool.Reserve()
+
+	assert.NotNil(t, first)
+	assert.NotNil(t, second)
+	assert.Nil(t, third)
+	assert.True(t, wait > 59*time.Minute)
+}
+
+func TestTokenPool_Update_accounts_for_requests_still_in_flight(t *testing.T) {
+	pool := NewTokenPool("a")
+	first, _ := pool.Reserve()
+	pool.Reserve()
+
+	pool.Update(first, GitHubHeader{RateLimitRemaining: 10, RateLimitReset: time.Now().Add(time.Hour)})
+
+	assert.Equal(t, 9, pool.Pick().RateLimitRemaining)
+}
+
+func TestTokenPool_Resource_tracks_a_separate_budget_for_the_same_tokens(t *testing.T) {
+	pool := NewTokenPool("a", "b")
+	search := pool.Resource("search")
+
+	assert.Equal(t, search, pool.Resource("search"))
+	assert.Len(t, search.Tokens(), 2)
+	assert.Equal(t, 30, search.Pick().RateLimitRemaining)
+	assert.Equal(t, DefaultRateLimit, pool.Pick().RateLimitRemaining)
+	assert.Equal(t, 10, NewTokenPool().Resource("search").Pick().RateLimitRemaining)
+}
@@ -0,0 +1,119 @@
+package github
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Error is returned for any request that did not produce a usable response.
+// Transient errors are worth retrying, permanent ones will fail again.
+type Error struct {
+	URL        string
+	StatusCode int
+	RetryAfter time.Duration
+	Transient  bool
+	// SecondaryRateLimit is set when GitHub asked us to slow down regardless
+	// of the remaining budget, which holds for every token.
+	SecondaryRateLimit bool
+	Err                error
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("github: %s: %v", e.URL, e.Err)
+	}
+	return fmt.Sprintf("github: %s: %d %s", e.URL, e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func IsTransient(err error) bool {
+	e, ok := err.(*Error)
+	return ok && e.Transient
+}
+
+func IsPermanent(err error) bool {
+	e, ok := err.(*Error)
+	return ok && !e.Transient
+}
+
+func IsNotFound(err error) bool {
+	e, ok := err.(*Error)
+	return ok && e.StatusCode == http.StatusNotFound
+}
+
+// transportError wraps an error of the transport, which is transient unless
+// the transport already classified it, as a Cassette does.
+func transportError(url string, err error) *Error {
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+	return &Error{URL: url, Err: err, Transient: true}
+}
+
+// SecondaryRateLimitDelay is how long to back off from a secondary rate limit
+// that came without a Retry-After.
+const SecondaryRateLimitDelay = time.Minute
+
+// statusError classifies a response that is not a 2xx or 304. Server errors
+// and rate limits are transient; everything else, notably 404, 409 (empty
+// repository) and 451 (unavailable for legal reasons), is permanent.
+func statusError(url string, resp *http.Response) *Error {
+	e := &Error{URL: url, StatusCode: resp.StatusCode}
+	header := ParseHeader(resp.Header)
+
+	switch {
+	case resp.StatusCode >= 500:
+		e.Transient = true
+	case resp.StatusCode == http.StatusTooManyRequests:
+		e.Transient = true
+		e.SecondaryRateLimit = true
+		e.RetryAfter = retryAfter(resp.Header)
+	case resp.StatusCode == http.StatusForbidden && resp.Header.Get("Retry-After") != "":
+		e.Transient = true
+		e.SecondaryRateLimit = true
+		e.RetryAfter = retryAfter(resp.Header)
+	case resp.StatusCode == http.StatusForbidden && resp.Header.Get("X-RateLimit-Remaining") == "0":
+		e.Transient = true
+		e.RetryAfter = header.RateLimitReset.Sub(time.Now())
+	case resp.StatusCode == http.StatusForbidden && secondaryRateLimited(resp):
+		e.Transient = true
+		e.SecondaryRateLimit = true
+		e.RetryAfter = SecondaryRateLimitDelay
+	}
+	if e.RetryAfter < 0 {
+		e.RetryAfter = 0
+	}
+	return e
+}
+
+// secondaryRateLimited tells a 403 for a secondary rate limit, which GitHub
+// only explains in the message, from one for missing permissions.
+func secondaryRateLimited(resp *http.Response) bool {
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, 4096))
+	if err != nil {
+		return false
+	}
+	message := strings.ToLower(string(body))
+	return strings.Contains(message, "secondary rate limit") || strings.Contains(message, "abuse")
+}
+
+func retryAfter(header http.Header) time.Duration {
+	value := header.Get("Retry-After")
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return at.Sub(time.Now())
+	}
+	return 0
+}
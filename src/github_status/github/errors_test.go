@@ -0,0 +1,106 @@
+package github
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func statusResponse(status int, header http.Header) *http.Response {
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{StatusCode: status, Header: header}
+}
+
+func TestStatusError_classifies_server_errors_as_transient(t *testing.T) {
+	assert.True(t, statusError("u", statusResponse(502, nil)).Transient)
+}
+
+func TestStatusError_classifies_missing_and_unavailable_repos_as_permanent(t *testing.T) {
+	for _, status := range []int{404, 409, 451} {
+		err := statusError("u", statusResponse(status, nil))
+
+		assert.False(t, err.Transient)
+		assert.True(t, IsPermanent(err))
+	}
+}
+
+func TestStatusError_honors_retry_after_on_secondary_rate_limits(t *testing.T) {
+	header := http.Header{}
+	header.Set("Retry-After", "30")
+
+	err := statusError("u", statusResponse(http.StatusForbidden, header))
+
+	assert.True(t, err.Transient)
+	assert.Equal(t, 30*time.Second, err.RetryAfter)
+}
+
+func TestStatusError_waits_for_the_reset_when_the_rate_limit_is_exhausted(t *testing.T) {
+	header := http.Header{}
+	header.Set("X-RateLimit-Remaining", "0")
+	header.Set("X-RateLimit-Reset", fmt.Sprint(time.Now().Add(time.Minute).Unix()))
+
+	err := statusError("u", statusResponse(http.StatusForbidden, header))
+
+	assert.True(t, err.Transient)
+	assert.True(t, err.RetryAfter > 58*time.Second)
+}
+
+func TestIsTransient_is_false_for_foreign_errors(t *testing.T) {
+	assert.False(t, IsTransient(errors.New("boom")))
+	assert.False(t, IsPermanent(errors.New("boom")))
+}
+
+func TestClient_get_retries_transient_errors(t *testing.T) {
+	defer func() { sleep = sleepUntil }()
+	sleep = func(<-chan struct{}, time.Duration) bool { return false }
+
+	requests := 0
+	fakeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		fmt.Fprint(w, `{"Go": 10}`)
+	}))
+	defer fakeServer.Close()
+
+	languages := map[string]int{}
+	_, err := fakeClient(fakeServer).get("repos/a/b/languages", nil, &languages)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 3, requests)
+	assert.Equal(t, map[string]int{"Go": 10}, languages)
+}
+
+func TestClient_get_does_not_retry_permanent_errors(t *testing.T) {
+	requests := 0
+	fakeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer fakeServer.Close()
+
+	_, err := fakeClient(fakeServer).get("repos/a/b/languages", nil, &map[string]int{})
+
+	assert.True(t, IsNotFound(err))
+	assert.Equal(t, 1, requests)
+}
+
+func TestClient_get_reports_undecodable_bodies(t *testing.T) {
+	fakeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "hello")
+	}))
+	defer fakeServer.Close()
+
+	_, err := fakeClient(fakeServer).get("repos/a/b/languages", nil, &map[string]int{})
+
+	assert.True(t, IsPermanent(err))
+}
@@ -0,0 +1,212 @@
+package github
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// GraphQLBatchSize is the most repositories fetched in one query.
+const GraphQLBatchSize = 100
+
+const graphQLLanguages = 100
+
+// RateLimit is the GraphQL API's own account of a query: its cost in points
+// and the points left in the window.
+type RateLimit struct {
+	Cost      int       `json:"cost"`
+	Limit     int       `json:"limit"`
+	Remaining int       `json:"remaining"`
+	ResetAt   time.Time `json:"resetAt"`
+}
+
+type BatchItem struct {
+	Repo      Repo
+	Languages map[string]int
+	Err       error
+}
+
+type graphQLRepository struct {
+	DatabaseID     int64     `json:"databaseId"`
+	NameWithOwner  string    `json:"nameWithOwner"`
+	IsFork         bool      `json:"isFork"`
+	IsArchived     bool      `json:"isArchived"`
+	StargazerCount int       `json:"stargazerCount"`
+	DiskUsage      int       `json:"diskUsage"`
+	CreatedAt      time.Time `json:"createdAt"`
+	PushedAt       time.Time `json:"pushedAt"`
+	Owner          struct {
+		Login    string `json:"login"`
+		Typename string `json:"__typename"`
+	} `json:"owner"`
+	Languages struct {
+		Edges []struct {
+			Size int `json:"size"`
+			Node struct {
+				Name string `json:"name"`
+			} `json:"node"`
+		} `json:"edges"`
+	} `json:"languages"`
+}
+
+func (r graphQLRepository) repo() Repo {
+	name := r.NameWithOwner
+	if i := strings.Index(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	return Repo{
+		ID:              r.DatabaseID,
+		Name:            name,
+		FullName:        r.NameWithOwner,
+		Owner:           Owner{Login: r.Owner.Login, Type: r.Owner.Typename},
+		Fork:            r.IsFork,
+		Archived:        r.IsArchived,
+		StargazersCount: r.StargazerCount,
+		Size:            r.DiskUsage,
+		CreatedAt:       r.CreatedAt,
+		PushedAt:        r.PushedAt,
+	}
+}
+
+type graphQLResponse struct {
+	Data   map[string]json.RawMessage `json:"data"`
+	Errors []struct {
+		Type    string        `json:"type"`
+		Message string        `json:"message"`
+		Path    []interface{} `json:"path"`
+	} `json:"errors"`
+}
+
+// UnmarshalJSON starts from an empty response, so the errors of an attempt
+// that is retried do not stay around.
+func (r *graphQLResponse) UnmarshalJSON(data []byte) error {
+	type plain graphQLResponse
+	var fresh plain
+	err := json.Unmarshal(data, &fresh)
+	*r = graphQLResponse(fresh)
+	return err
+}
+
+// validate fails the whole query when GraphQL reports errors that belong to
+// no repository, such as RATE_LIMITED, or returns no data at all. Errors of
+// single repositories are left to BatchLanguages.
+func (r *graphQLResponse) validate(url string) *Error {
+	var messages []string
+	transient := false
+	for _, e := range r.Errors {
+		if len(e.Path) > 0 {
+			continue
+		}
+		message := e.Type
+		if e.Message != "" {
+			message = strings.TrimSpace(e.Type + " " + e.Message)
+		}
+		messages = append(messages, message)
+		transient = transient || transientGraphQL(e.Type)
+	}
+	if len(messages) == 0 && r.Data != nil {
+		return nil
+	}
+	if len(messages) == 0 {
+		messages = append(messages, "no data")
+	}
+	return &Error{URL: url, StatusCode: http.StatusOK, Err: fmt.Errorf("graphql: %s", strings.Join(messages, "; ")), Transient: transient}
+}
+
+func transientGraphQL(kind string) bool {
+	return kind == "RATE_LIMITED" || kind == "INTERNAL"
+}
+
+// BatchLanguages fetches up to GraphQLBatchSize repositories, given by full
+// name, in one aliased query against the v4 API, where REST needs a request
+// per repository. Items come back in the order asked for; a repository that
+// does not exist gets a permanent *Error instead of languages. A query that
+// failed as a whole, for instance when rate limited, is retried like any
+// other request and then returned as the error.
+func (c *Client) BatchLanguages(fullNames []string) ([]BatchItem, RateLimit, error) {
+	var rateLimit RateLimit
+	if len(fullNames) > GraphQLBatchSize {
+		return nil, rateLimit, fmt.Errorf("github: %d repositories in one GraphQL batch, at most %d allowed", len(fullNames), GraphQLBatchSize)
+	}
+
+	payload, err := json.Marshal(map[string]string{"query": languagesQuery(fullNames)})
+	if err != nil {
+		return nil, rateLimit, err
+	}
+
+	var response graphQLResponse
+	endpoint := c.GraphQLURL.String()
+	if _, err := c.request("POST", endpoint, payload, c.Tokens.Resource("graphql"), &response); err != nil {
+		return nil, rateLimit, err
+	}
+	if raw, ok := response.Data["rateLimit"]; ok {
+		json.Unmarshal(raw, &rateLimit)
+	}
+	c.Metrics.graphQL(rateLimit.Cost)
+
+	failures := make(map[string]string)
+	for _, e := range response.Errors {
+		if len(e.Path) > 0 {
+			if alias, ok := e.Path[0].(string); ok {
+				failures[alias] = e.Type
+			}
+		}
+	}
+
+	items := make([]BatchItem, len(fullNames))
+	for i, fullName := range fullNames {
+		alias := fmt.Sprintf("r%d", i)
+		items[i].Repo = Repo{FullName: fullName}
+
+		var repository *graphQLRepository
+		if raw, ok := response.Data[alias]; ok {
+			if err := json.Unmarshal(raw, &repository); err != nil {
+				items[i].Err = &Error{URL: endpoint, Err: err}
+				continue
+			}
+		}
+		if repository == nil {
+			items[i].Err = graphQLError(endpoint, fullName, failures[alias])
+			continue
+		}
+
+		items[i].Repo = repository.repo()
+		items[i].Languages = make(map[string]int)
+		for _, edge := range repository.Languages.Edges {
+			items[i].Languages[edge.Node.Name] = edge.Size
+		}
+	}
+	return items, rateLimit, nil
+}
+
+func graphQLError(url string, fullName string, kind string) *Error {
+	if kind == "NOT_FOUND" || kind == "" {
+		return &Error{URL: url, StatusCode: 404, Err: fmt.Errorf("repository %s not found", fullName)}
+	}
+	return &Error{URL: url, Err: fmt.Errorf("repository %s: %s", fullName, kind), Transient: transientGraphQL(kind)}
+}
+
+func languagesQuery(fullNames []string) string {
+	var query bytes.Buffer
+	query.WriteString("query {\n  rateLimit { cost limit remaining resetAt }\n")
+	for i, fullName := range fullNames {
+		owner, name := fullName, ""
+		if j := strings.Index(fullName, "/"); j >= 0 {
+			owner, name = fullName[:j], fullName[j+1:]
+		}
+		quotedOwner, _ := json.Marshal(owner)
+		quotedName, _ := json.Marshal(name)
+		fmt.Fprintf(&query, "  r%d: repository(owner: %s, name: %s) { ...repo }\n", i, quotedOwner, quotedName)
+	}
+	fmt.Fprintf(&query, `}
+fragment repo on Repository {
+  databaseId nameWithOwner isFork isArchived stargazerCount diskUsage createdAt pushedAt
+  owner { login __typename }
+  languages(first: %d, orderBy: {field: SIZE, direction: DESC}) { edges { size node { name } } }
+}
+`, graphQLLanguages)
+	return query.String()
+}
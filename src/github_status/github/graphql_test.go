@@ -0,0 +1,113 @@
+package github
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLanguagesQuery_aliases_every_repository(t *testing.T) {
+	query := languagesQuery([]string{"golang/go", "rust-lang/rust"})
+
+	assert.Contains(t, query, `r0: repository(owner: "golang", name: "go") { ...repo }`)
+	assert.Contains(t, query, `r1: repository(owner: "rust-lang", name: "rust") { ...repo }`)
+	assert.Contains(t, query, "rateLimit { cost limit remaining resetAt }")
+	assert.Contains(t, query, "languages(first: 100")
+}
+
+func TestClient_BatchLanguages_decodes_a_batch(t *testing.T) {
+	var query string
+	fakeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		var payload map[string]string
+		json.Unmarshal(body, &payload)
+		query = payload["query"]
+
+		assert.Equal(t, "POST", r.Method)
+		assert.Equal(t, "/graphql", r.URL.Path)
+		assert.Equal(t, "token secret", r.Header.Get("Authorization"))
+		fmt.Fprint(w, `{
+			"data": {
+				"rateLimit": {"cost": 1, "limit": 5000, "remaining": 4990, "resetAt": "2024-05-01T10:00:00Z"},
+				"r0": {
+					"databaseId": 4164482, "nameWithOwner": "golang/go", "isFork": false, "isArchived": false,
+					"stargazerCount": 120000, "diskUsage": 300000, "createdAt": "2014-08-19T04:33:40Z",
+					"owner": {"login": "golang", "__typename": "Organization"},
+					"languages": {"edges": [{"size": 900, "node": {"name": "Go"}}, {"size": 50, "node": {"name": "Assembly"}}]}
+				},
+				"r1": null
+			},
+			"errors": [{"type": "NOT_FOUND", "path": ["r1"], "message": "Could not resolve to a Repository"}]
+		}`)
+	}))
+	defer fakeServer.Close()
+
+	client := fakeClient(fakeServer)
+	client.Tokens = NewTokenPool("secret")
+	items, rateLimit, err := client.BatchLanguages([]string{"golang/go", "gone/away"})
+
+	assert.Nil(t, err)
+	assert.True(t, strings.Contains(query, `r1: repository(owner: "gone", name: "away")`))
+	assert.Equal(t, RateLimit{Cost: 1, Limit: 5000, Remaining: 4990, ResetAt: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)}, rateLimit)
+
+	assert.Len(t, items, 2)
+	assert.Equal(t, "golang/go", items[0].Repo.FullName)
+	assert.Equal(t, "go", items[0].Repo.Name)
+	assert.Equal(t, Owner{Login: "golang", Type: "Organization"}, items[0].Repo.Owner)
+	assert.Equal(t, 120000, items[0].Repo.StargazersCount)
+	assert.True(t, items[0].Repo.Detailed())
+	assert.Equal(t, map[string]int{"Go": 900, "Assembly": 50}, items[0].Languages)
+
+	assert.Equal(t, "gone/away", items[1].Repo.FullName)
+	assert.True(t, IsNotFound(items[1].Err))
+}
+
+func TestClient_BatchLanguages_refuses_oversized_batches(t *testing.T) {
+	_, _, err := NewClient(nil).BatchLanguages(make([]string, GraphQLBatchSize+1))
+
+	assert.NotNil(t, err)
+}
+
+func TestClient_BatchLanguages_retries_a_rate_limited_query(t *testing.T) {
+	defer func() { sleep = sleepUntil }()
+	sleep = func(<-chan struct{}, time.Duration) bool { return false }
+	requests := 0
+	fakeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests++; requests == 1 {
+			fmt.Fprint(w, `{"data": null, "errors": [{"type": "RATE_LIMITED", "message": "API rate limit exceeded"}]}`)
+			return
+		}
+		fmt.Fprint(w, `{"data": {"r0": {"nameWithOwner": "a/b", "languages": {"edges": []}}}}`)
+	}))
+	defer fakeServer.Close()
+
+	items, _, err := fakeClient(fakeServer).BatchLanguages([]string{"a/b"})
+
+	assert.Nil(t, err)
+	assert.Equal(t, 2, requests)
+	assert.Nil(t, items[0].Err)
+}
+
+func TestClient_BatchLanguages_fails_a_query_rejected_as_a_whole(t *testing.T) {
+	requests := 0
+	fakeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		fmt.Fprint(w, `{"errors": [{"type": "MAX_NODE_LIMIT_EXCEEDED", "message": "too many nodes"}]}`)
+	}))
+	defer fakeServer.Close()
+
+	items, _, err := fakeClient(fakeServer).BatchLanguages([]string{"a/b", "c/d"})
+
+	assert.Nil(t, items)
+	assert.True(t, IsPermanent(err))
+	assert.False(t, IsNotFound(err))
+	assert.Contains(t, err.Error(), "MAX_NODE_LIMIT_EXCEEDED too many nodes")
+	assert.Equal(t, 1, requests)
+}
@@ -0,0 +1,9 @@
+package github
+
+import "fmt"
+
+func (c *Client) GetLanguages(fullName string) (map[string]int, GitHubHeader, error) {
+	languages := make(map[string]int)
+	header, err := c.get(fmt.Sprintf("repos/%s/languages", fullName), c.Tokens, &languages)
+	return languages, header, err
+}
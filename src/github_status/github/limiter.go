@@ -0,0 +1,253 @@
+package github
+
+import (
+	"time"
+)
+
+// DefaultBurst is how many requests a token may make ahead of its pace.
+const DefaultBurst = 10
+
+// Rather than bursting until a token is exhausted and then sleeping until its
+// reset, the pool spreads each token's remaining budget evenly over what is
+// left of its window: a token with 1000 calls left and 20 minutes to go is
+// handed out at most once every 1.2 seconds, plus a small burst. Secondary
+// rate limits pause every resource of the pool until GitHub's Retry-After.
+
+// Reserve takes one request from the budget of the best token that is due.
+// When no token is it returns no token and how long to wait instead.
+func (p *TokenPool) Reserve() (*Token, time.Duration) {
+	if p == nil {
+		return nil, 0
+	}
+	if wait := p.root().paused(time.Now()); wait > 0 {
+		return nil, wait
+	}
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	now := time.Now()
+	best, wait := p.available(now)
+	if best == nil {
+		return nil, wait
+	}
+
+	if best.nextSlot.Before(now) {
+		best.nextSlot = now
+	}
+	best.nextSlot = best.nextSlot.Add(pace(best, now))
+	best.RateLimitRemaining--
+	best.inFlight++
+	return best, 0
+}
+
+// available returns the token with the most budget among those due now, or
+// how long until the first one is.
+func (p *TokenPool) available(now time.Time) (*Token, time.Duration) {
+	var best *Token
+	var wait time.Duration = -1
+	for _, token := range p.tokens {
+		refresh(token, now)
+
+		var delay time.Duration
+		if token.RateLimitRemaining <= 0 {
+			delay = token.RateLimitReset.Sub(now)
+			if delay <= 0 {
+				delay = time.Second
+			}
+		} else if token.nextSlot.After(now) {
+			delay = token.nextSlot.Sub(now) - pace(token, now)*time.Duration(p.burst()-1)
+		}
+
+		if delay > 0 {
+			if wait < 0 || delay < wait {
+				wait = delay
+			}
+			continue
+		}
+		if best == nil || token.RateLimitRemaining > best.RateLimitRemaining {
+			best = token
+		}
+	}
+	return best, wait
+}
+
+// pace is the interval that spends the token's remaining budget evenly by its
+// reset. Until GitHub has reported a reset there is nothing to pace against.
+func pace(token *Token, now time.Time) time.Duration {
+	if token.RateLimitReset.IsZero() || token.RateLimitRemaining <= 0 || !token.RateLimitReset.After(now) {
+		return 0
+	}
+	return token.RateLimitReset.Sub(now) / time.Duration(token.RateLimitRemaining)
+}
+
+func (p *TokenPool) burst() int {
+	if burst := p.root().Burst; burst > 0 {
+		return burst
+	}
+	return DefaultBurst
+}
+
+// WaitDuration is how long the caller has to wait before any token in the
+// pool can be used again.
+func (p *TokenPool) WaitDuration() time.Duration {
+	if p == nil {
+		return 0
+	}
+	if wait := p.root().paused(time.Now()); wait > 0 {
+		return wait
+	}
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if best, wait := p.available(time.Now()); best == nil {
+		return wait
+	}
+	return 0
+}
+
+// Pause stops every resource of the pool from handing out tokens for d, as
+// GitHub asks after a secondary rate limit.
+func (p *TokenPool) Pause(d time.Duration) {
+	if p == nil {
+		return
+	}
+	root := p.root()
+	root.mutex.Lock()
+	defer root.mutex.Unlock()
+
+	if until := time.Now().Add(d); until.After(root.pausedUntil) {
+		root.pausedUntil = until
+	}
+}
+
+func (p *TokenPool) paused(now time.Time) time.Duration {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if !p.pausedUntil.After(now) {
+		return 0
+	}
+	return p.pausedUntil.Sub(now)
+}
+
+// Update records the rate limit GitHub reported for a request signed with
+// token and releases that request's reservation. When the response says it
+// was counted against another resource, that resource's pool is updated. A
+// response without rate limit headers, as from a GitHub Enterprise Server
+// with rate limiting off, or no response at all gives the call back.
+func (p *TokenPool) Update(token *Token, header GitHubHeader) {
+	if p == nil || token == nil {
+		return
+	}
+	if resource := header.RateLimitResource; resource != "" && resource != p.resourceName() {
+		p.release(token)
+		p.Resource(resource).observe(token.Value, header)
+		return
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if token.inFlight > 0 {
+		token.inFlight--
+	}
+	if !header.RateLimitReset.After(time.Unix(0, 0)) {
+		if token.RateLimitRemaining < token.RateLimit {
+			token.RateLimitRemaining++
+		}
+		return
+	}
+	apply(token, header)
+}
+
+func (p *TokenPool) resourceName() string {
+	if p.resource == "" {
+		return "core"
+	}
+	return p.resource
+}
+
+func (p *TokenPool) release(token *Token) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if token.inFlight > 0 {
+		token.inFlight--
+	}
+}
+
+func (p *TokenPool) observe(value string, header GitHubHeader) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	for _, token := range p.tokens {
+		if token.Value == value {
+			apply(token, header)
+		}
+	}
+}
+
+func apply(token *Token, header GitHubHeader) {
+	if !header.RateLimitReset.After(time.Unix(0, 0)) {
+		return
+	}
+	if header.RateLimitLimit > 0 {
+		token.RateLimit = header.RateLimitLimit
+	}
+	token.RateLimitUsed = header.RateLimitUsed
+
+	if header.RateLimitReset.After(token.RateLimitReset) {
+		token.RateLimitRemaining = header.RateLimitRemaining - token.inFlight
+		token.RateLimitReset = header.RateLimitReset
+	} else if header.RateLimitRemaining < token.RateLimitRemaining {
+		token.RateLimitRemaining = header.RateLimitRemaining
+	}
+	if token.RateLimitRemaining < 0 {
+		token.RateLimitRemaining = 0
+	}
+}
+
+// refund gives back the call reserved for a conditional request that GitHub
+// answered with 304, which does not count against the rate limit.
+func (p *TokenPool) refund(token *Token) {
+	if p == nil || token == nil {
+		return
+	}
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if token.RateLimitRemaining < token.RateLimit {
+		token.RateLimitRemaining++
+	}
+}
+
+type rateLimitResponse struct {
+	Resources map[string]struct {
+		Limit     int   `json:"limit"`
+		Remaining int   `json:"remaining"`
+		Used      int   `json:"used"`
+		Reset     int64 `json:"reset"`
+	} `json:"resources"`
+}
+
+// SyncRateLimits asks /rate_limit, which is free, for the budget of every
+// token and resource so pacing starts from GitHub's numbers rather than
+// defaults.
+func (c *Client) SyncRateLimits() error {
+	for _, token := range c.Tokens.Tokens() {
+		var limits rateLimitResponse
+		if _, err := c.get("rate_limit", NewTokenPool(token.Value), &limits); err != nil {
+			return err
+		}
+		for name, limit := range limits.Resources {
+			c.Tokens.Resource(name).observe(token.Value, GitHubHeader{
+				RateLimitLimit:     limit.Limit,
+				RateLimitRemaining: limit.Remaining,
+				RateLimitUsed:      limit.Used,
+				RateLimitReset:     time.Unix(limit.Reset, 0),
+				RateLimitResource:  name,
+			})
+		}
+	}
+	return nil
+}
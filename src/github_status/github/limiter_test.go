@@ -0,0 +1,127 @@
+package github
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenPool_Reserve_spreads_the_remaining_budget_until_the_reset(t *testing.T) {
+	pool := NewTokenPool("a")
+	pool.Burst = 2
+	pool.Update(pool.Pick(), GitHubHeader{RateLimitRemaining: 100, RateLimitReset: time.Now().Add(100 * time.Second)})
+
+	first, _ := pool.Reserve()
+	second, _ := pool.Reserve()
+	third, wait := pool.Reserve()
+
+	assert.NotNil(t, first)
+	assert.NotNil(t, second)
+	assert.Nil(t, third)
+	assert.True(t, wait > 900*time.Millisecond)
+	assert.True(t, wait <= 2*time.Second)
+}
+
+func TestTokenPool_Reserve_uses_another_token_while_one_is_ahead_of_its_pace(t *testing.T) {
+	pool := NewTokenPool("a", "b")
+	pool.Burst = 1
+	reset := time.Now().Add(time.Hour)
+	for _, token := range pool.tokens {
+		pool.Update(token, GitHubHeader{RateLimitRemaining: 10, RateLimitReset: reset})
+	}
+
+	first, _ := pool.Reserve()
+	second, _ := pool.Reserve()
+
+	assert.NotNil(t, second)
+	assert.NotEqual(t, first.Value, second.Value)
+}
+
+func TestTokenPool_Pause_holds_every_resource(t *testing.T) {
+	pool := NewTokenPool("a")
+	pool.Resource("search").Pause(time.Minute)
+
+	token, wait := pool.Reserve()
+
+	assert.Nil(t, token)
+	assert.True(t, wait > 59*time.Second)
+	assert.True(t, pool.Resource("graphql").WaitDuration() > 59*time.Second)
+}
+
+func TestTokenPool_Update_applies_headers_to_the_resource_they_came_from(t *testing.T) {
+	pool := NewTokenPool("a")
+	token, _ := pool.Reserve()
+
+	pool.Update(token, GitHubHeader{RateLimitLimit: 30, RateLimitRemaining: 12, RateLimitReset: time.Now().Add(time.Minute), RateLimitResource: "search"})
+
+	assert.Equal(t, DefaultRateLimit-1, pool.Pick().RateLimitRemaining)
+	assert.Equal(t, 12, pool.Resource("search").Pick().RateLimitRemaining)
+	assert.Equal(t, 0, pool.tokens[0].inFlight)
+}
+
+func TestClient_SyncRateLimits_seeds_every_resource_from_rate_limit(t *testing.T) {
+	reset := time.Now().Add(time.Hour).Unix()
+	fakeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/rate_limit", r.URL.Path)
+		fmt.Fprintf(w, `{"resources": {
+			"core": {"limit": 5000, "used": 1200, "remaining": 3800, "reset": %d},
+			"search": {"limit": 30, "used": 5, "remaining": 25, "reset": %d}
+		}}`, reset, reset)
+	}))
+	defer fakeServer.Close()
+
+	client := fakeClient(fakeServer)
+	client.Tokens = NewTokenPool("a")
+	err := client.SyncRateLimits()
+
+	assert.Nil(t, err)
+	core := client.Tokens.Pick()
+	assert.Equal(t, 3800, core.RateLimitRemaining)
+	assert.Equal(t, 1200, core.RateLimitUsed)
+	assert.Equal(t, time.Unix(reset, 0), core.RateLimitReset)
+	assert.Equal(t, 25, client.Tokens.Resource("search").Pick().RateLimitRemaining)
+}
+
+func TestClient_pauses_the_pool_on_a_secondary_rate_limit(t *testing.T) {
+	requests := 0
+	fakeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("X-RateLimit-Remaining", "4000")
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10))
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, `{"message": "You have exceeded a secondary rate limit."}`)
+	}))
+	defer fakeServer.Close()
+
+	client := fakeClient(fakeServer)
+	client.Tokens = NewTokenPool("a")
+	client.Retry = RetryPolicy{MaxAttempts: 1}
+	_, _, err := client.GetLanguages("a/b")
+
+	assert.True(t, IsTransient(err))
+	assert.Equal(t, 1, requests)
+	assert.True(t, client.Tokens.WaitDuration() > 59*time.Second)
+}
+
+func TestClient_keeps_going_when_the_server_sends_no_rate_limit_headers(t *testing.T) {
+	fakeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"Go": 1}`)
+	}))
+	defer fakeServer.Close()
+
+	client := fakeClient(fakeServer)
+	client.Tokens = NewTokenPool("secret")
+	for i := 0; i <= DefaultRateLimit; i++ {
+		if _, _, err := client.GetLanguages("a/b"); err != nil {
+			t.Fatalf("request %d: %v", i, err)
+		}
+	}
+
+	assert.Equal(t, time.Duration(0), client.Tokens.WaitDuration())
+	assert.Equal(t, DefaultRateLimit, client.Tokens.Pick().RateLimitRemaining)
+}
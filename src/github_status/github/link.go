@@ -0,0 +1,104 @@
+package github
+
+import (
+	"net/url"
+	"strings"
+)
+
+// Links maps each relation type of an RFC 8288 Link header to its target.
+type Links map[string]*url.URL
+
+// ParseLinks parses a Link header value. Commas and semicolons inside the
+// <URI> or inside quoted parameter values do not split links, parameters may
+// come in any order, and a rel listing several relation types ("next last")
+// registers the link under each of them. The first link for a relation wins.
+func ParseLinks(value string) Links {
+	links := make(Links)
+	s := value
+	for {
+		s = strings.TrimLeft(s, " \t,")
+		if s == "" {
+			return links
+		}
+		if !strings.HasPrefix(s, "<") {
+			next := strings.Index(s, ",")
+			if next < 0 {
+				return links
+			}
+			s = s[next+1:]
+			continue
+		}
+		end := strings.Index(s, ">")
+		if end < 0 {
+			return links
+		}
+		target, err := url.Parse(strings.TrimSpace(s[1:end]))
+		s = s[end+1:]
+
+		var params map[string]string
+		params, s = parseLinkParams(s)
+
+		if err != nil {
+			continue
+		}
+		for _, rel := range strings.Fields(strings.ToLower(params["rel"])) {
+			if _, ok := links[rel]; !ok {
+				links[rel] = target
+			}
+		}
+	}
+}
+
+// parseLinkParams reads `; name=value` pairs up to the comma ending the
+// link and returns them along with the unparsed rest of the header.
+func parseLinkParams(s string) (map[string]string, string) {
+	params := make(map[string]string)
+	for {
+		s = strings.TrimLeft(s, " \t")
+		if !strings.HasPrefix(s, ";") {
+			return params, s
+		}
+		s = strings.TrimLeft(s[1:], " \t")
+
+		end := strings.IndexAny(s, "=;,")
+		if end < 0 {
+			end = len(s)
+		}
+		name := strings.ToLower(strings.TrimSpace(s[:end]))
+		s = s[end:]
+
+		value := ""
+		if strings.HasPrefix(s, "=") {
+			value, s = parseLinkParamValue(strings.TrimLeft(s[1:], " \t"))
+		}
+		if _, ok := params[name]; !ok && name != "" {
+			params[name] = value
+		}
+	}
+}
+
+func parseLinkParamValue(s string) (string, string) {
+	if !strings.HasPrefix(s, `"`) {
+		end := strings.IndexAny(s, ";,")
+		if end < 0 {
+			end = len(s)
+		}
+		return strings.TrimSpace(s[:end]), s[end:]
+	}
+
+	var value strings.Builder
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			if i+1 < len(s) {
+				i++
+				value.WriteByte(s[i])
+			}
+		case '"':
+			return value.String(), s[i+1:]
+		default:
+			value.WriteByte(s[i])
+		}
+	}
+	return value.String(), ""
+}
@@ -0,0 +1,40 @@
+package github
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseLinks_returns_every_relation(t *testing.T) {
+	links := ParseLinks(`<https://api.github.com/r?page=1>; rel="prev", <https://api.github.com/r?page=3>; rel="next", <https://api.github.com/r?page=5>; rel="last", <https://api.github.com/r?page=1>; rel="first"`)
+
+	assert.Equal(t, "https://api.github.com/r?page=1", links["prev"].String())
+	assert.Equal(t, "https://api.github.com/r?page=3", links["next"].String())
+	assert.Equal(t, "https://api.github.com/r?page=5", links["last"].String())
+	assert.Equal(t, "https://api.github.com/r?page=1", links["first"].String())
+}
+
+func TestParseLinks_handles_params_in_any_order_and_quoting(t *testing.T) {
+	links := ParseLinks(`<https://example.com/a,b;c>; title="a, \"quoted\"; title"; rel=next ; type="text/html"`)
+
+	assert.Equal(t, "https://example.com/a,b;c", links["next"].String())
+}
+
+func TestParseLinks_registers_multiple_relation_types(t *testing.T) {
+	links := ParseLinks(`<https://example.com/5>; rel="next last"`)
+
+	assert.Equal(t, "https://example.com/5", links["next"].String())
+	assert.Equal(t, "https://example.com/5", links["last"].String())
+}
+
+func TestParseLinks_ignores_case_of_relations_and_malformed_links(t *testing.T) {
+	links := ParseLinks(`garbage, <https://example.com/2>; REL="Next", <https://example.com/9`)
+
+	assert.Len(t, links, 1)
+	assert.Equal(t, "https://example.com/2", links["next"].String())
+}
+
+func TestParseLinks_of_an_empty_header_is_empty(t *testing.T) {
+	assert.Len(t, ParseLinks(""), 0)
+}
@@ -0,0 +1,120 @@
+package github
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// RequestKey identifies a group of requests: the endpoint with the
+// repository replaced by placeholders, and the status code or "error" when
+// there was no response.
+type RequestKey struct {
+	Endpoint string
+	Status   string
+}
+
+// Metrics counts the requests a Client makes. It is safe for concurrent use.
+type Metrics struct {
+	mutex    sync.Mutex
+	requests map[RequestKey]int
+	retries  map[string]int
+	// graphQLCost is the points GraphQL charged for every query so far.
+	graphQLCost int
+}
+
+func NewMetrics() *Metrics {
+	return &Metrics{requests: make(map[RequestKey]int), retries: make(map[string]int)}
+}
+
+func (m *Metrics) Requests() map[RequestKey]int {
+	if m == nil {
+		return nil
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	requests := make(map[RequestKey]int, len(m.requests))
+	for key, count := range m.requests {
+		requests[key] = count
+	}
+	return requests
+}
+
+func (m *Metrics) Retries() map[string]int {
+	if m == nil {
+		return nil
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	retries := make(map[string]int, len(m.retries))
+	for endpoint, count := range m.retries {
+		retries[endpoint] = count
+	}
+	return retries
+}
+
+func (m *Metrics) request(endpoint string, status int) {
+	if m == nil {
+		return
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	key := RequestKey{Endpoint: endpoint, Status: "error"}
+	if status > 0 {
+		key.Status = strconv.Itoa(status)
+	}
+	m.requests[key]++
+}
+
+func (m *Metrics) retry(endpoint string) {
+	if m == nil {
+		return
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.retries[endpoint]++
+}
+
+// endpoint names the API endpoint of u without the owner and name of the
+// repository, so that metrics stay few: /repos/{owner}/{repo}/languages.
+func (c *Client) endpoint(u *url.URL) string {
+	if c.GraphQLURL != nil && u.Path == c.GraphQLURL.Path {
+		return "/graphql"
+	}
+	path := u.Path
+	if c.BaseURL != nil {
+		path = strings.TrimPrefix(path, strings.TrimSuffix(c.BaseURL.Path, "/"))
+	}
+
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	if len(parts) >= 3 && parts[0] == "repos" {
+		parts[1], parts[2] = "{owner}", "{repo}"
+	}
+	return "/" + strings.Join(parts, "/")
+}
+
+// GraphQLCost is the total of the points GraphQL charged so far.
+func (m *Metrics) GraphQLCost() int {
+	if m == nil {
+		return 0
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return m.graphQLCost
+}
+
+func (m *Metrics) graphQL(cost int) {
+	if m == nil {
+		return
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.graphQLCost += cost
+}
@@ -0,0 +1,59 @@
+package github
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient_endpoint_hides_the_repository(t *testing.T) {
+	client, _ := NewEnterpriseClient("https://github.example.com/api/v3/", nil)
+	endpoint := func(raw string) string {
+		u, _ := url.Parse(raw)
+		return client.endpoint(u)
+	}
+
+	assert.Equal(t, "/repos/{owner}/{repo}/languages", endpoint("https://github.example.com/api/v3/repos/golang/go/languages"))
+	assert.Equal(t, "/repos/{owner}/{repo}", endpoint("https://github.example.com/api/v3/repos/golang/go"))
+	assert.Equal(t, "/repositories", endpoint("https://github.example.com/api/v3/repositories?since=5"))
+	assert.Equal(t, "/graphql", endpoint("https://github.example.com/api/graphql"))
+}
+
+func TestClient_counts_requests_by_status_and_retries(t *testing.T) {
+	defer func() { sleep = sleepUntil }()
+	sleep = func(<-chan struct{}, time.Duration) bool { return false }
+
+	requests := 0
+	fakeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		fmt.Fprint(w, `{"Go": 10}`)
+	}))
+	defer fakeServer.Close()
+
+	client := fakeClient(fakeServer)
+	_, _, err := client.GetLanguages("a/b")
+
+	assert.Nil(t, err)
+	assert.Equal(t, map[RequestKey]int{
+		{Endpoint: "/repos/{owner}/{repo}/languages", Status: "502"}: 1,
+		{Endpoint: "/repos/{owner}/{repo}/languages", Status: "200"}: 1,
+	}, client.Metrics.Requests())
+	assert.Equal(t, map[string]int{"/repos/{owner}/{repo}/languages": 1}, client.Metrics.Retries())
+}
+
+func TestMetrics_nil_counts_nothing(t *testing.T) {
+	var metrics *Metrics
+
+	metrics.request("/repositories", 200)
+
+	assert.Nil(t, metrics.Requests())
+}
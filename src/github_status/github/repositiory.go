@@ -1,28 +1,66 @@
 package github
 
 import (
-	"net/http"
-	"io/ioutil"
-	"encoding/json"
+	"fmt"
+	"time"
 )
 
+type Owner struct {
+	Login     string `json:"login"`
+	ID        int64  `json:"id"`
+	Type      string `json:"type"`
+	SiteAdmin bool   `json:"site_admin"`
+}
+
+type License struct {
+	Key    string `json:"key"`
+	Name   string `json:"name"`
+	SPDXID string `json:"spdx_id"`
+}
+
+// Repo is a repository as returned by /repos/{owner}/{name} and the search
+// API. /repositories only fills in the identity, owner, description and fork
+// flag; Detailed tells the two apart.
 type Repo struct {
-	Full_name string
+	ID              int64     `json:"id"`
+	NodeID          string    `json:"node_id"`
+	Name            string    `json:"name"`
+	FullName        string    `json:"full_name"`
+	Owner           Owner     `json:"owner"`
+	Private         bool      `json:"private"`
+	Description     string    `json:"description"`
+	HTMLURL         string    `json:"html_url"`
+	Fork            bool      `json:"fork"`
+	Archived        bool      `json:"archived"`
+	Disabled        bool      `json:"disabled"`
+	Language        string    `json:"language"`
+	StargazersCount int       `json:"stargazers_count"`
+	WatchersCount   int       `json:"watchers_count"`
+	ForksCount      int       `json:"forks_count"`
+	OpenIssuesCount int       `json:"open_issues_count"`
+	Size            int       `json:"size"`
+	DefaultBranch   string    `json:"default_branch"`
+	License         *License  `json:"license"`
+	Topics          []string  `json:"topics"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+	PushedAt        time.Time `json:"pushed_at"`
 }
 
-func GetRepos(url string) ([]Repo, GitHubHeader) {
-	resp, err := http.Get(url)
-	if err != nil {
-		panic(err)
-	}
+func (r Repo) Detailed() bool {
+	return !r.CreatedAt.IsZero()
+}
 
-	body, _ := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		panic(err)
-	}
+func (c *Client) GetRepo(fullName string) (Repo, GitHubHeader, error) {
+	var repo Repo
+	header, err := c.get(fmt.Sprintf("repos/%s", fullName), c.Tokens, &repo)
+	return repo, header, err
+}
 
+// GetRepos fetches a page of repositories from path, which is relative to
+// BaseURL or an absolute URL from a Link header.
+func (c *Client) GetRepos(path string) ([]Repo, GitHubHeader, error) {
 	var repos []Repo
-	json.Unmarshal(body, &repos)
-
-	return repos, ParseHeader(resp.Header)
+	header, err := c.get(path, c.Tokens, &repos)
+	return repos, header, err
 }
@@ -5,17 +5,88 @@ import (
 	"net/http/httptest"
 	"net/http"
 	"fmt"
+	"encoding/json"
+	"io/ioutil"
+	"time"
 	"github.com/stretchr/testify/assert"
 )
 
 func TestGetRepo_returns_AHydratedRepo(t *testing.T) {
 	fakeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		fmt.Fprintln(w, "hello")
+		assert.Equal(t, "/repos/octocat/Hello-World", r.URL.Path)
+		http.ServeFile(w, r, "testdata/repo.json")
 	}))
 	defer fakeServer.Close()
 
-	repo, header := GetRepos("http://someplace.com")
+	repo, _, err := fakeClient(fakeServer).GetRepo("octocat/Hello-World")
 
-	assert.NotNil(t, repo)
-	assert.NotNil(t, header)
+	assert.Nil(t, err)
+	assert.Equal(t, "octocat/Hello-World", repo.FullName)
+	assert.True(t, repo.Detailed())
+}
+
+func TestGetRepos_returns_a_page_and_its_header(t *testing.T) {
+	fakeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/repositories", r.URL.Path)
+		assert.Equal(t, "369", r.URL.Query().Get("since"))
+		w.Header().Set("Link", fmt.Sprintf(`<%s/repositories?since=27>; rel="next"`, "http://"+r.Host))
+		w.Header().Set("X-RateLimit-Remaining", "4999")
+		http.ServeFile(w, r, "testdata/repositories.json")
+	}))
+	defer fakeServer.Close()
+
+	repos, header, err := fakeClient(fakeServer).GetRepos("repositories?since=369")
+
+	assert.Nil(t, err)
+	assert.Len(t, repos, 3)
+	assert.Equal(t, 4999, header.RateLimitRemaining)
+	assert.Equal(t, fakeServer.URL+"/repositories?since=27", header.Next.String())
+}
+
+func TestGetLanguages_returns_the_bytes_per_language(t *testing.T) {
+	fakeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/repos/golang/go/languages", r.URL.Path)
+		fmt.Fprint(w, `{"Go": 900, "Assembly": 50}`)
+	}))
+	defer fakeServer.Close()
+
+	languages, _, err := fakeClient(fakeServer).GetLanguages("golang/go")
+
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]int{"Go": 900, "Assembly": 50}, languages)
+}
+
+func TestRepo_decodes_the_repositories_listing(t *testing.T) {
+	body, _ := ioutil.ReadFile("testdata/repositories.json")
+
+	var repos []Repo
+	assert.Nil(t, json.Unmarshal(body, &repos))
+
+	assert.Len(t, repos, 3)
+	assert.Equal(t, int64(1), repos[0].ID)
+	assert.Equal(t, "mojombo/grit", repos[0].FullName)
+	assert.Equal(t, Owner{Login: "mojombo", ID: 1, Type: "User"}, repos[0].Owner)
+	assert.False(t, repos[0].Fork)
+	assert.True(t, repos[2].Fork)
+	assert.Equal(t, "Organization", repos[2].Owner.Type)
+	assert.False(t, repos[0].Detailed())
+}
+
+func TestRepo_decodes_a_full_repository(t *testing.T) {
+	body, _ := ioutil.ReadFile("testdata/repo.json")
+
+	var repo Repo
+	assert.Nil(t, json.Unmarshal(body, &repo))
+
+	assert.Equal(t, "octocat/Hello-World", repo.FullName)
+	assert.Equal(t, "octocat", repo.Owner.Login)
+	assert.True(t, repo.Archived)
+	assert.Equal(t, 2700, repo.StargazersCount)
+	assert.Equal(t, 108, repo.Size)
+	assert.Equal(t, "", repo.Language)
+	assert.Equal(t, &License{Key: "mit", Name: "MIT License", SPDXID: "MIT"}, repo.License)
+	assert.Equal(t, []string{"octocat", "atom", "electron", "api"}, repo.Topics)
+	assert.Equal(t, time.Date(2011, 1, 26, 19, 1, 12, 0, time.UTC), repo.CreatedAt)
+	assert.Equal(t, time.Date(2024, 4, 20, 17, 23, 4, 0, time.UTC), repo.PushedAt)
+	assert.True(t, repo.Detailed())
 }
@@ -5,34 +5,67 @@ import (
 	"net/url"
 	"time"
 	"strconv"
-	"regexp"
+	"strings"
 )
 
 type GitHubHeader struct {
+	Links                     Links
 	Next                      *url.URL
+	Prev                      *url.URL
+	First                     *url.URL
+	Last                      *url.URL
+	RateLimitLimit            int
 	RateLimitRemaining        int
+	RateLimitUsed             int
 	RateLimitReset            time.Time
+	RateLimitResource         string
+	RetryAfter                time.Duration
 }
 
 func ParseHeader(header http.Header) GitHubHeader {
+	links := ParseLinks(strings.Join(header["Link"], ", "))
 	return GitHubHeader{
+		RateLimitLimit: getInt(header, "X-RateLimit-Limit"),
 		RateLimitRemaining: getRateLimitRemaining(header),
+		RateLimitUsed: getInt(header, "X-RateLimit-Used"),
 		RateLimitReset: getRateLimitResetTime(header),
-		Next: getNextPageLink(header),
+		RateLimitResource: header.Get("X-RateLimit-Resource"),
+		RetryAfter: retryAfter(header),
+		Links: links,
+		Next: links["next"],
+		Prev: links["prev"],
+		First: links["first"],
+		Last: links["last"],
 	}
 }
 
-func getNextPageLink(header http.Header) *url.URL {
-	re := regexp.MustCompile(`<(.*?)>; rel="next"`)
-	next_link_match := re.FindStringSubmatch(header.Get("Link"))
+// Page is the number of the page this header came with, or 0 when the
+// endpoint is not paginated by page number.
+func (h GitHubHeader) Page() int {
+	if page := pageNumber(h.Next); page > 0 {
+		return page - 1
+	}
+	if page := pageNumber(h.Prev); page > 0 {
+		return page + 1
+	}
+	return 0
+}
 
-	next_page := ""
-	if len(next_link_match) != 0 {
-		next_page = next_link_match[1]
+// EstimatedTotalPages is the page number of the last link, or 0 for
+// endpoints such as /repositories that never provide one.
+func (h GitHubHeader) EstimatedTotalPages() int {
+	if h.Last == nil && h.Prev != nil && h.Next == nil {
+		return h.Page()
 	}
+	return pageNumber(h.Last)
+}
 
-	next_page_url, _ := url.Parse(next_page)
-	return next_page_url
+func pageNumber(link *url.URL) int {
+	if link == nil {
+		return 0
+	}
+	page, _ := strconv.Atoi(link.Query().Get("page"))
+	return page
 }
 
 func getRateLimitRemaining(header http.Header) int {
@@ -40,6 +73,11 @@ func getRateLimitRemaining(header http.Header) int {
 	return rate_limit
 }
 
+func getInt(header http.Header, name string) int {
+	value, _ := strconv.Atoi(header.Get(name))
+	return value
+}
+
 func getRateLimitResetTime(header http.Header) time.Time{
 	reset, _ := strconv.Atoi(header.Get("X-RateLimit-Reset"))
 	return time.Unix(int64(reset), 0)
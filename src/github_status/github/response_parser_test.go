@@ -37,3 +37,51 @@ func TestParseHeader_returns_a_GitHubHeader_with_the_next_page_link(t *testing.T
 	assert.Nil(t, error)
 	assert.Equal(t, *expected, *actual)
 }
+
+func TestParseHeader_returns_a_GitHubHeader_without_a_next_page_link_on_the_last_page(t *testing.T) {
+	header := http.Header{}
+	header.Add("Link", `<https://api.github.com/resource?page=4>; rel="prev", <https://api.github.com/resource?page=1>; rel="first"`)
+
+	parsed := ParseHeader(header)
+
+	assert.Nil(t, parsed.Next)
+	assert.Nil(t, parsed.Last)
+	assert.Equal(t, "https://api.github.com/resource?page=1", parsed.First.String())
+	assert.Equal(t, 5, parsed.Page())
+	assert.Equal(t, 5, parsed.EstimatedTotalPages())
+}
+
+func TestParseHeader_estimates_the_total_pages_from_the_last_link(t *testing.T) {
+	header := http.Header{}
+	header.Add("Link", `<https://api.github.com/resource?page=2>; rel="next", <https://api.github.com/resource?page=34>; rel="last"`)
+
+	parsed := ParseHeader(header)
+
+	assert.Equal(t, 1, parsed.Page())
+	assert.Equal(t, 34, parsed.EstimatedTotalPages())
+}
+
+func TestParseHeader_has_no_page_estimate_for_since_cursors(t *testing.T) {
+	header := http.Header{}
+	header.Add("Link", `<https://api.github.com/repositories?since=369>; rel="next", <https://api.github.com/repositories{?since}>; rel="first"`)
+
+	parsed := ParseHeader(header)
+
+	assert.Equal(t, "369", parsed.Next.Query().Get("since"))
+	assert.Equal(t, 0, parsed.EstimatedTotalPages())
+}
+
+func TestParseHeader_returns_the_limit_used_and_resource_of_the_rate_limit(t *testing.T) {
+	header := http.Header{}
+	header.Add("X-RateLimit-Limit", "30")
+	header.Add("X-RateLimit-Used", "4")
+	header.Add("X-RateLimit-Resource", "search")
+	header.Add("Retry-After", "7")
+
+	parsed := ParseHeader(header)
+
+	assert.Equal(t, 30, parsed.RateLimitLimit)
+	assert.Equal(t, 4, parsed.RateLimitUsed)
+	assert.Equal(t, "search", parsed.RateLimitResource)
+	assert.Equal(t, 7*time.Second, parsed.RetryAfter)
+}
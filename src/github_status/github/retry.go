@@ -0,0 +1,82 @@
+package github
+
+import (
+	"errors"
+	"math/rand"
+	"time"
+)
+
+// RetryPolicy retries transient errors with exponential backoff. Jitter is the
+// fraction of each delay that is randomized, so concurrent workers that fail
+// together do not retry together.
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+	Jitter      float64
+}
+
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 5,
+	BaseDelay:   time.Second,
+	MaxDelay:    time.Minute,
+	Jitter:      0.5,
+}
+
+// ErrStopped is returned for a request given up because Client.Stop was
+// closed.
+var ErrStopped = errors.New("github: stopped")
+
+var sleep = sleepUntil
+
+// sleepUntil waits for d unless stop is closed first, and reports whether it
+// was.
+func sleepUntil(stop <-chan struct{}, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return false
+	case <-stop:
+		return true
+	}
+}
+
+// Delay is how long to wait before the given retry (starting at 1). A
+// Retry-After sent by GitHub always wins over the computed backoff.
+func (r RetryPolicy) Delay(retry int, err error) time.Duration {
+	if e, ok := err.(*Error); ok && e.RetryAfter > 0 {
+		return e.RetryAfter
+	}
+
+	delay := r.BaseDelay
+	for i := 1; i < retry; i++ {
+		delay *= 2
+		if r.MaxDelay > 0 && delay >= r.MaxDelay {
+			break
+		}
+	}
+	if r.MaxDelay > 0 && delay > r.MaxDelay {
+		delay = r.MaxDelay
+	}
+	if r.Jitter > 0 {
+		spread := time.Duration(float64(delay) * r.Jitter)
+		delay = delay - spread + time.Duration(rand.Int63n(int64(spread)*2+1))
+	}
+	return delay
+}
+
+// Do calls f until it succeeds, fails permanently or runs out of attempts. A
+// backoff cut short by closing stop returns ErrStopped.
+func (r RetryPolicy) Do(stop <-chan struct{}, f func() error) error {
+	var err error
+	for attempt := 1; ; attempt++ {
+		err = f()
+		if err == nil || !IsTransient(err) || attempt >= r.MaxAttempts {
+			return err
+		}
+		if sleep(stop, r.Delay(attempt, err)) {
+			return ErrStopped
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package github
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryPolicy_Delay_doubles_up_to_the_maximum(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: time.Second, MaxDelay: 5 * time.Second}
+
+	assert.Equal(t, time.Second, policy.Delay(1, nil))
+	assert.Equal(t, 2*time.Second, policy.Delay(2, nil))
+	assert.Equal(t, 4*time.Second, policy.Delay(3, nil))
+	assert.Equal(t, 5*time.Second, policy.Delay(4, nil))
+}
+
+func TestRetryPolicy_Delay_stays_within_the_jitter(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: time.Second, MaxDelay: time.Minute, Jitter: 0.5}
+
+	for i := 0; i < 100; i++ {
+		delay := policy.Delay(1, nil)
+		assert.True(t, delay >= 500*time.Millisecond && delay <= 1500*time.Millisecond)
+	}
+}
+
+func TestRetryPolicy_Delay_prefers_retry_after(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: time.Second, Jitter: 0.5}
+
+	assert.Equal(t, time.Minute, policy.Delay(1, &Error{Transient: true, RetryAfter: time.Minute}))
+}
+
+func TestRetryPolicy_Do_gives_up_after_max_attempts(t *testing.T) {
+	defer func() { sleep = sleepUntil }()
+	var slept []time.Duration
+	sleep = func(_ <-chan struct{}, d time.Duration) bool {
+		slept = append(slept, d)
+		return false
+	}
+
+	attempts := 0
+	err := RetryPolicy{MaxAttempts: 3, BaseDelay: time.Second}.Do(nil, func() error {
+		attempts++
+		return &Error{Transient: true}
+	})
+
+	assert.True(t, IsTransient(err))
+	assert.Equal(t, 3, attempts)
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Second}, slept)
+}
+
+func TestRetryPolicy_Do_returns_other_errors_immediately(t *testing.T) {
+	attempts := 0
+	RetryPolicy{MaxAttempts: 3}.Do(nil, func() error {
+		attempts++
+		return errors.New("boom")
+	})
+
+	assert.Equal(t, 1, attempts)
+}
+
+func TestRetryPolicy_Do_stops_backing_off_when_stopped(t *testing.T) {
+	stop := make(chan struct{})
+	close(stop)
+
+	attempts := 0
+	err := RetryPolicy{MaxAttempts: 3, BaseDelay: time.Hour}.Do(stop, func() error {
+		attempts++
+		return &Error{Transient: true}
+	})
+
+	assert.Equal(t, ErrStopped, err)
+	assert.Equal(t, 1, attempts)
+}
@@ -0,0 +1,27 @@
+package github
+
+import (
+	"fmt"
+	"net/url"
+)
+
+// SearchResultLimit is the most results the search API returns for a query,
+// however many pages are requested.
+const SearchResultLimit = 1000
+
+const SearchPerPage = 100
+
+type SearchResult struct {
+	TotalCount        int    `json:"total_count"`
+	IncompleteResults bool   `json:"incomplete_results"`
+	Items             []Repo `json:"items"`
+}
+
+// SearchRepos returns one page of /search/repositories. Searches are counted
+// against the "search" budget of the tokens, not the core one.
+func (c *Client) SearchRepos(query string, page int) (SearchResult, GitHubHeader, error) {
+	var result SearchResult
+	search := fmt.Sprintf("search/repositories?q=%s&per_page=%d&page=%d", url.QueryEscape(query), SearchPerPage, page)
+	header, err := c.get(search, c.Tokens.Resource("search"), &result)
+	return result, header, err
+}
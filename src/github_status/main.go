@@ -1,107 +1,494 @@
 package main
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
+	"flag"
 	"fmt"
-	"io/ioutil"
+	"io"
+	"net"
 	"net/http"
-	"net/url"
+	"os"
+	"strings"
+	"sync"
 	"time"
-	"sort"
+	"github_status/catalog"
+	"github_status/crawler"
 	"github_status/github"
+	"github_status/stats"
+	"github_status/storage"
 )
 
+// Data is one update from the crawl: a repo with its languages, replacing
+// Previous when the repo was counted before, and/or page progress.
 type Data struct {
-	Language map[string]int
+	Repo *storage.RepoLanguages
+	Previous *storage.RepoLanguages
 	Limit int
+	Reset time.Time
+	Pages int
+	TotalPages int
+	Filtered map[string]int
+	Sample *crawler.Estimate
 }
 
-func getLanguageForRep(repo string) map[string]int {
-	languages := make(map[string]int)
-	resp, err := http.Get(addUserInfo(fmt.Sprintf("https://api.github.com/repos/%s/languages", repo)))
-	if err != nil {
-		panic(err)
+// getAllRepos aggregates every page of source until it is exhausted or stop
+// is closed. A page cut short by stop is neither counted nor checkpointed, so
+// a resumed crawl fetches it again. Only a crawl of /repositories has a cursor
+// to checkpoint; checkpointer is nil for other sources.
+func getAllRepos(c chan Data, stop <-chan struct{}, source crawler.Source, fetcher crawler.Fetcher, filter crawler.Filter, store *storage.Store, checkpointer storage.Checkpointer, checkpoint storage.Checkpoint) error {
+	if checkpoint.Languages == nil {
+		checkpoint.Languages = make(map[string]int)
+	}
+	if checkpoint.Series == nil {
+		checkpoint.Series = make(map[string]stats.Series)
 	}
 
-	body, _ := ioutil.ReadAll(resp.Body)
-	json.Unmarshal(body, &languages)
-	return languages
-}
-
-func getAllRepos(c chan Data) {
-	next := "https://api.github.com/repositories"
-	for {
-		if addUserInfo(next) == "" {
-			break
+	for pages := 1; ; pages++ {
+		select {
+		case <-stop:
+			return nil
+		default:
 		}
 
-		repos, header := getRepos(addUserInfo(next))
-		if header.RateLimitRemaining == 0 {
-			fmt.Printf("waiting unil %v\n", header.RateLimitReset)
-			time.Sleep(header.RateLimitReset.Sub(time.Now()))
+		page, err := source.Next()
+		if err == io.EOF || errors.Is(err, github.ErrStopped) {
+			return nil
+		}
+		if err != nil {
+			return err
 		}
+		repos, header := page.Repos, page.Header
 
+		filtered := make(map[string]int)
+		var wanted []github.Repo
 		for _, repo := range repos {
-			for lang, bytes := range getLanguageForRep(repo.Full_name) {
-				c <- Data{Language: map[string]int{lang: bytes}, Limit: header.RateLimitRemaining}
+			if reason := filter.Reject(repo); reason != "" {
+				filtered[reason]++
+				continue
+			}
+			wanted = append(wanted, repo)
+		}
+
+		results := fetcher.Fetch(wanted)
+		for _, result := range results {
+			if errors.Is(result.Err, github.ErrStopped) {
+				return nil
 			}
 		}
+		for _, result := range results {
+			if result.Filtered != "" {
+				filtered[result.Filtered]++
+				continue
+			}
+			if result.Err != nil {
+				fmt.Fprintf(os.Stderr, "skipping %s: %v\n", result.Repo.FullName, result.Err)
+				continue
+			}
+
+			repo := storage.FromRepo(result.Repo, time.Now(), result.Languages)
+			var previous *storage.RepoLanguages
+			if store != nil {
+				if previous, err = store.SaveRepo(repo); err != nil {
+					return err
+				}
+			}
 
-		next = header.Next.String()
+			for lang, bytes := range repo.Languages {
+				checkpoint.Languages[lang] += bytes
+			}
+			storage.CountSeries(checkpoint.Series, repo, 1)
+			if previous != nil {
+				for lang, bytes := range previous.Languages {
+					checkpoint.Languages[lang] -= bytes
+				}
+				storage.CountSeries(checkpoint.Series, *previous, -1)
+			}
+			c <- Data{Repo: &repo, Previous: previous, Limit: header.RateLimitRemaining, Reset: header.RateLimitReset}
+		}
+
+		progress := Data{Limit: header.RateLimitRemaining, Reset: header.RateLimitReset, Pages: pages, TotalPages: header.EstimatedTotalPages(), Filtered: filtered}
+		switch source := source.(type) {
+		case *crawler.Sample:
+			estimate := source.Estimate()
+			progress.TotalPages, progress.Sample = source.TotalPages(), &estimate
+		case *crawler.Search:
+			// The last page link only covers the slice of the search in
+			// progress, and how many slices follow is not known.
+			progress.TotalPages = 0
+		}
+		c <- progress
+
+		if checkpointer == nil {
+			continue
+		}
+		if repositories, ok := source.(*crawler.Repositories); ok {
+			checkpoint.Since = repositories.Since
+		}
+		checkpoint.Processed += len(repos)
+		checkpoint.UpdatedAt = time.Now()
+		if err := checkpointer.Save(checkpoint); err != nil {
+			return err
+		}
 	}
 }
 
-func addUserInfo(url_string string) string {
-	parsed_url, _ := url.Parse(url_string)
-	parsed_url.User = url.UserPassword("austinbv", "b528d870c0e40a0ff1603c8b3c93fb6b5e7d1cb9")
-	return parsed_url.String()
+func printLanguages(c *catalog.Catalog) {
+	for _, language := range c.Languages() {
+		fmt.Printf("%s\t%s", language.Name, language.Type)
+		if language.Group != "" {
+			fmt.Printf("\t-> %s", language.Group)
+		}
+		fmt.Println()
+	}
 }
 
+// typesList is the -types value that selects types, "all" for none.
+func typesList(types []catalog.Type) string {
+	if len(types) == 0 {
+		return "all"
+	}
+	names := make([]string, len(types))
+	for i, t := range types {
+		names[i] = string(t)
+	}
+	return strings.Join(names, ",")
+}
 
-
-func clear() {
-	for i := 0; i < 500; i++ {
-		fmt.Print("\033[1A")
-		fmt.Print("\033[80X")
+func splitList(list string) []string {
+	var values []string
+	for _, value := range strings.Split(list, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
 	}
+	return values
 }
 
 func main() {
-	languages := make(map[string]int)
+	os.Exit(run())
+}
+
+// run crawls with a terminal dashboard, or with "serve" as the first
+// argument, behind an HTTP server instead. "languages" lists the language
+// catalog, "series" the stored shares over time and "export" writes the
+// stored results to files. It returns the exit code.
+func run() int {
+	args := os.Args[1:]
+	command := "crawl"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		command, args = args[0], args[1:]
+	}
+	switch command {
+	case "crawl", "serve", "series", "export":
+	case "languages":
+		printLanguages(catalog.Default())
+		return exitDone
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q, want crawl, serve, series, export or languages\n", command)
+		return exitUsage
+	}
+	serve := command == "serve"
+
+	listen := flag.String("listen", ":8080", "address to serve stats on in serve mode")
+	mongo := flag.String("mongo", os.Getenv("MONGO_URL"), "MongoDB URL to persist crawled repositories to")
+	database := flag.String("database", storage.DefaultDatabase, "MongoDB database name")
+	checkpointPath := flag.String("checkpoint", "github_stats.checkpoint.json", "file to checkpoint the crawl to when not using MongoDB")
+	resume := flag.Bool("resume", false, "resume the crawl from the last checkpoint")
+	cache := flag.String("cache", "", `cache GitHub responses for conditional requests: "memory" or a directory`)
+	types := flag.String("types", "all", "comma separated language types to count: programming, markup, data, prose")
+	group := flag.Bool("group", false, "count languages such as TSX as the language of their group")
+	weighting := flag.String("weighting", string(stats.Raw), "what the dashboard shares are of: raw bytes, log bytes, one vote per repo split by bytes, one vote for the primary language, or bytes capped per repo (raw, log, vote, primary, capped)")
+	weightCap := flag.Int("weight-cap", stats.DefaultCap, "most bytes one repository counts for under the capped weighting")
+	top := flag.Int("top", DefaultTopLanguages, "number of languages to show before grouping the rest as Other")
+	workers := flag.Int("workers", 8, "number of concurrent languages requests")
+	graphql := flag.Bool("graphql", false, "fetch languages in batches through the GraphQL API instead of one REST request per repository")
+	details := flag.Bool("details", true, "fetch the details of every repository listed without them, for the dates series are bucketed by; false saves a REST request per repository but leaves the series empty")
+	retry := github.DefaultRetryPolicy
+	flag.IntVar(&retry.MaxAttempts, "retries", retry.MaxAttempts, "attempts per request before a transient error is given up on")
+	flag.DurationVar(&retry.BaseDelay, "retry-delay", retry.BaseDelay, "initial backoff between retries")
+	flag.DurationVar(&retry.MaxDelay, "retry-max-delay", retry.MaxDelay, "longest backoff between retries")
+	api := flag.String("api", github.DefaultBaseURL, "GitHub API base URL, https://HOST/api/v3/ for GitHub Enterprise")
+	userAgent := flag.String("user-agent", github.DefaultUserAgent, "User-Agent sent to GitHub")
+	timeout := flag.Duration("timeout", 30*time.Second, "timeout for a single GitHub request")
+	record := flag.String("record", "", "record every GitHub request and response to this directory")
+	replay := flag.String("replay", "", "replay GitHub responses recorded with -record from this directory instead of using the network")
+	var filter crawler.Filter
+	flag.BoolVar(&filter.SkipForks, "skip-forks", false, "do not count forks")
+	flag.BoolVar(&filter.SkipArchived, "skip-archived", false, "do not count archived repositories")
+	flag.IntVar(&filter.MinStars, "min-stars", 0, "only count repositories with at least this many stars")
+	flag.IntVar(&filter.MinSize, "min-size", 0, "only count repositories of at least this size in KB")
+	owners := flag.String("owners", "", "comma separated owners to restrict the crawl to")
+	excludeOwners := flag.String("exclude-owners", "", "comma separated owners to leave out")
+	createdAfter := flag.String("created-after", "", "only count repositories created after this date (2006-01-02)")
+	search := flag.String("search", "", "crawl the results of this repository search instead of /repositories (not checkpointed)")
+	searchFrom := flag.String("search-from", crawler.GitHubFounded.Format("2006-01-02"), "earliest creation date to search")
+	searchTo := flag.String("search-to", "", "latest creation date to search, inclusive, defaults to now")
+	samplePages := flag.Int("sample", 0, "draw this many pages at random IDs across all of GitHub instead of crawling in order, and estimate totals from them (not checkpointed)")
+	sampleSeed := flag.Int64("sample-seed", time.Now().UnixNano(), "seed of the random IDs drawn by -sample, to repeat a sample")
+	date := flag.String("date", storage.Created, "in series mode, the date of repositories to bucket by: created or pushed")
+	period := flag.String("period", string(stats.Year), "in series mode, the width of the buckets: month, quarter or year")
+	from := flag.String("from", "", "in series mode, the bucket to compare from, such as 2018, defaults to the first")
+	to := flag.String("to", "", "in series mode, the bucket to compare to, defaults to the last")
+	csvOutput := flag.Bool("csv", false, "in series mode, write CSV instead of a table")
+	out := flag.String("out", "export", "in export mode, the directory to write to")
+	formats := flag.String("formats", strings.Join(exportFormats, ","), "in export mode, comma separated formats to write: csv, jsonl, md, html")
+	flag.CommandLine.Parse(args)
+
+	filter.AllowOwners = splitList(*owners)
+	filter.DenyOwners = splitList(*excludeOwners)
+	if *createdAfter != "" {
+		var err error
+		filter.CreatedAfter, err = time.Parse("2006-01-02", *createdAfter)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "invalid -created-after: %v\n", err)
+			return exitUsage
+		}
+	}
+
+	aggregator := NewAggregator()
+	mode := catalog.Mode{Group: *group}
+	var err error
+	if mode.Types, err = catalog.ParseTypes(*types); err != nil {
+		fmt.Fprintf(os.Stderr, "invalid -types: %v\n", err)
+		return exitUsage
+	}
+	if len(mode.Types) > 0 || mode.Group {
+		aggregator.Normalize = func(languages map[string]int) map[string]int {
+			return catalog.Default().Apply(mode, languages)
+		}
+	}
+	aggregator.WeightCap = *weightCap
+	dashboardWeighting, err := stats.ParseWeighting(*weighting)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid -weighting: %v\n", err)
+		return exitUsage
+	}
+	if command == "series" {
+		report := seriesReport{From: *from, To: *to, Top: *top, CSV: *csvOutput, Normalize: aggregator.Normalize}
+		if report.Period, err = stats.ParsePeriod(*period); err != nil {
+			fmt.Fprintf(os.Stderr, "invalid -period: %v\n", err)
+			return exitUsage
+		}
+		return runSeries(*mongo, *database, *checkpointPath, *date, report)
+	}
+	if command == "export" {
+		header := exportHeader{
+			Schema:      exportSchema,
+			GeneratedAt: time.Now().UTC().Truncate(time.Second),
+			Filters:     append([]string{}, filter.Flags()...),
+			Types:       typesList(mode.Types),
+			Group:       mode.Group,
+			Weighting:   string(dashboardWeighting),
+			WeightCap:   *weightCap,
+		}
+		return runExport(exporter{Dir: *out, Formats: splitList(*formats), Top: *top}, header, *mongo, *database, *checkpointPath, filter, aggregator)
+	}
 	c := make(chan Data, 500)
-	limit := 0
-
-	go getAllRepos(c)
-	go func(languages map[string]int, limit *int) {
-		for {
-			keys := make([]string, len(languages))
-			i := 0
-			for key := range languages {
-				keys[i] = key
-				i++
-			}
 
-			sum := 0.0
-			for _, v := range languages {
-				sum += float64(v)
+	tokens, err := github.LoadTokenPool(os.Getenv)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitFailed
+	}
+
+	client, err := github.NewEnterpriseClient(*api, tokens)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid -api: %v\n", err)
+		return exitUsage
+	}
+	client.UserAgent = *userAgent
+	client.HTTPClient.Timeout = *timeout
+	client.Retry = retry
+	if *record != "" {
+		client.HTTPClient.Transport = github.NewCassette(*record, github.Record, nil)
+	}
+	if *replay != "" {
+		client.HTTPClient.Transport = github.NewCassette(*replay, github.Replay, nil)
+	}
+	switch *cache {
+	case "":
+	case "memory":
+		client.Cache = github.NewMemoryCache()
+	default:
+		client.Cache = github.DiskCache{Dir: *cache}
+	}
+	if tokens.Pick().Anonymous() {
+		if *graphql {
+			fmt.Fprintln(os.Stderr, "-graphql needs a token: set GITHUB_TOKEN, GITHUB_TOKENS or GITHUB_TOKENS_FILE")
+			return exitUsage
+		}
+		fmt.Println("no GITHUB_TOKEN, GITHUB_TOKENS or GITHUB_TOKENS_FILE set, crawling unauthenticated")
+	}
+	if err := client.SyncRateLimits(); err != nil {
+		fmt.Fprintf(os.Stderr, "could not read /rate_limit, pacing from defaults: %v\n", err)
+	}
+
+	var store *storage.Store
+	if *mongo != "" {
+		store, err = storage.Dial(*mongo, *database)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitFailed
+		}
+		defer store.Close()
+	}
+
+	var checkpointer storage.Checkpointer = storage.FileCheckpointer{Path: *checkpointPath}
+	if store != nil {
+		checkpointer = store.Checkpoints()
+	}
+
+	var checkpoint storage.Checkpoint
+	if *resume {
+		var found bool
+		checkpoint, found, err = checkpointer.Load()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitFailed
+		}
+		if !found {
+			fmt.Println("no checkpoint found, starting from the beginning")
+		}
+
+		repos := make(map[string]int)
+		if store != nil {
+			totals, err := store.LanguageTotals()
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				return exitFailed
 			}
+			checkpoint.Languages = make(map[string]int)
+			for _, total := range totals {
+				checkpoint.Languages[total.Name] = int(total.Bytes)
+				repos[total.Name] = total.Repos
+			}
+		}
+		aggregator.Seed(checkpoint.Languages, repos)
+	}
 
-			clear()
-			fmt.Printf("Limit:\t%v\n", *limit)
-			fmt.Println("____________")
-			sort.Strings(keys)
-			for _, v := range keys {
-				fmt.Printf("%s:\t%v%%\n", v, int(float64(languages[v])/sum*100))
+	checkpoint.Filters, checkpoint.Types, checkpoint.Group = filter.Flags(), typesList(mode.Types), mode.Group
+
+	var source crawler.Source = crawler.NewRepositories(client, checkpoint.Since)
+	if *search != "" {
+		from, err := time.Parse("2006-01-02", *searchFrom)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "invalid -search-from: %v\n", err)
+			return exitUsage
+		}
+		to := time.Now()
+		if *searchTo != "" {
+			if to, err = time.Parse("2006-01-02", *searchTo); err != nil {
+				fmt.Fprintf(os.Stderr, "invalid -search-to: %v\n", err)
+				return exitUsage
 			}
+			to = to.Add(24*time.Hour - time.Second)
+		}
+		source = crawler.NewSearch(client, *search, from, to)
+		checkpointer = nil
+	}
+	if *samplePages > 0 {
+		if *search != "" {
+			fmt.Fprintln(os.Stderr, "-sample and -search cannot be combined")
+			return exitUsage
+		}
+		sample := crawler.NewSample(client, *samplePages, *sampleSeed)
+		maxID, err := sample.DiscoverMaxID()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "could not find the newest repository: %v\n", err)
+			return exitFailed
+		}
+		fmt.Printf("sampling %d pages of repository IDs up to %d, seed %d\n", *samplePages, maxID, *sampleSeed)
+		source = sample
+		checkpointer = nil
+	}
+
+	shutdown := NotifyShutdown()
+	client.Stop = shutdown.Stop
 
-			time.Sleep(1*time.Second)
+	var fetcher crawler.Fetcher
+	if *graphql {
+		fetcher = crawler.NewGraphQLFetcher(client, filter)
+	} else {
+		if !*details && *search == "" && !filter.NeedsDetails() {
+			fmt.Fprintln(os.Stderr, "-details=false: repositories are counted without creation and push dates, so series stay empty")
 		}
-	}(languages, &limit)
+		pool := crawler.NewPool(*workers, crawler.FetchLanguages(client, filter, *details))
+		pool.Stop = shutdown.Stop
+		defer pool.Close()
+		fetcher = pool
+	}
 
-	for data := range c {
-		limit = data.Limit
-		for a, z := range data.Language {
-			languages[a] += z
+	crawled := make(chan error, 1)
+	go func() {
+		err := getAllRepos(c, shutdown.Stop, source, fetcher, filter, store, checkpointer, checkpoint)
+		close(c)
+		crawled <- err
+	}()
+
+	done := make(chan struct{})
+	var drawn sync.WaitGroup
+	if serve {
+		server := &Server{Aggregator: aggregator, Client: client}
+		if store != nil {
+			server.Repos = store
 		}
+		// Cancelling the requests' context ends open /stats/stream responses,
+		// which would otherwise hold up the server's shutdown.
+		ctx, cancel := context.WithCancel(context.Background())
+		httpServer := &http.Server{Addr: *listen, Handler: server.Handler(), BaseContext: func(net.Listener) context.Context { return ctx }}
+		go func() {
+			fmt.Printf("serving stats on %s\n", *listen)
+			if err := httpServer.ListenAndServe(); err != http.ErrServerClosed {
+				fmt.Fprintf(os.Stderr, "serve: %v\n", err)
+				os.Exit(exitFailed)
+			}
+		}()
+		defer func() {
+			cancel()
+			ctx, cancelShutdown := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancelShutdown()
+			httpServer.Shutdown(ctx)
+		}()
+	} else {
+		dashboard := NewDashboard(os.Stdout)
+		dashboard.TopN = *top
+		dashboard.Weighting = dashboardWeighting
+		drawn.Add(1)
+		go func() {
+			defer drawn.Done()
+			for {
+				summary := aggregator.Stats()
+				dashboard.Draw(aggregator.Snapshot(), &summary, time.Now())
+				select {
+				case <-done:
+					return
+				case <-time.After(time.Second):
+				}
+			}
+		}()
+	}
+
+	for data := range c {
+		aggregator.Add(data)
+	}
+	err = <-crawled
+	close(done)
+	drawn.Wait()
+
+	switch {
+	case err != nil:
+		report(os.Stdout, aggregator.Snapshot(), aggregator.Stats(), "failed", time.Now())
+		fmt.Fprintf(os.Stderr, "crawl stopped: %v\n", err)
+		return exitFailed
+	case shutdown.Signal() != nil:
+		report(os.Stdout, aggregator.Snapshot(), aggregator.Stats(), "interrupted", time.Now())
+		return signalExitCode(shutdown.Signal())
+	}
+	report(os.Stdout, aggregator.Snapshot(), aggregator.Stats(), "finished", time.Now())
+	if serve {
+		fmt.Println("still serving stats, interrupt to quit")
+		<-shutdown.Stop
 	}
+	return exitDone
 }
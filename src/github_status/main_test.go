@@ -0,0 +1,143 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github_status/crawler"
+	"github_status/github"
+	"github_status/stats"
+	"github_status/storage"
+)
+
+type fakeSource struct {
+	pages []crawler.Page
+}
+
+func (f *fakeSource) Next() (crawler.Page, error) {
+	if len(f.pages) == 0 {
+		return crawler.Page{}, io.EOF
+	}
+	page := f.pages[0]
+	f.pages = f.pages[1:]
+	return page, nil
+}
+
+type fakeFetcher func(repos []github.Repo) []crawler.Result
+
+func (f fakeFetcher) Fetch(repos []github.Repo) []crawler.Result {
+	return f(repos)
+}
+
+type fakeCheckpointer struct {
+	saved []storage.Checkpoint
+}
+
+func (f *fakeCheckpointer) Load() (storage.Checkpoint, bool, error) {
+	return storage.Checkpoint{}, false, nil
+}
+
+func (f *fakeCheckpointer) Save(checkpoint storage.Checkpoint) error {
+	f.saved = append(f.saved, checkpoint)
+	return nil
+}
+
+func TestGetAllRepos_finishes_the_page_in_progress_when_stopped(t *testing.T) {
+	source := &fakeSource{pages: []crawler.Page{
+		{Repos: []github.Repo{{FullName: "a/a"}}},
+		{Repos: []github.Repo{{FullName: "b/b"}}},
+	}}
+	stop := make(chan struct{})
+	fetcher := fakeFetcher(func(repos []github.Repo) []crawler.Result {
+		close(stop)
+		return []crawler.Result{{Repo: repos[0], Languages: map[string]int{"Go": 10}}}
+	})
+	checkpointer := &fakeCheckpointer{}
+	c := make(chan Data, 10)
+
+	err := getAllRepos(c, stop, source, fetcher, crawler.Filter{}, nil, checkpointer, storage.Checkpoint{})
+
+	assert.Nil(t, err)
+	assert.Len(t, source.pages, 1)
+	assert.Len(t, checkpointer.saved, 1)
+	assert.Equal(t, 1, checkpointer.saved[0].Processed)
+	assert.Equal(t, map[string]int{"Go": 10}, checkpointer.saved[0].Languages)
+	assert.Equal(t, "a/a", (<-c).Repo.FullName)
+}
+
+func TestGetAllRepos_leaves_a_page_cut_short_out_of_the_checkpoint(t *testing.T) {
+	source := &fakeSource{pages: []crawler.Page{
+		{Repos: []github.Repo{{FullName: "a/a"}, {FullName: "b/b"}}},
+	}}
+	fetcher := fakeFetcher(func(repos []github.Repo) []crawler.Result {
+		return []crawler.Result{
+			{Repo: repos[0], Languages: map[string]int{"Go": 10}},
+			{Repo: repos[1], Err: github.ErrStopped},
+		}
+	})
+	checkpointer := &fakeCheckpointer{}
+	c := make(chan Data, 10)
+
+	err := getAllRepos(c, nil, source, fetcher, crawler.Filter{}, nil, checkpointer, storage.Checkpoint{})
+
+	assert.Nil(t, err)
+	assert.Empty(t, checkpointer.saved)
+	assert.Len(t, c, 0)
+}
+
+func TestGetAllRepos_counts_the_series_of_a_listing_without_details(t *testing.T) {
+	fakeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/a/a":
+			fmt.Fprint(w, `{"full_name": "a/a", "created_at": "2015-03-01T00:00:00Z", "pushed_at": "2020-06-01T00:00:00Z"}`)
+		case "/repos/a/a/languages":
+			fmt.Fprint(w, `{"Go": 10}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer fakeServer.Close()
+	client, _ := github.NewEnterpriseClient(fakeServer.URL, nil)
+	client.HTTPClient = fakeServer.Client()
+	pool := crawler.NewPool(1, crawler.FetchLanguages(client, crawler.Filter{}, true))
+	defer pool.Close()
+	source := &fakeSource{pages: []crawler.Page{{Repos: []github.Repo{{FullName: "a/a"}}}}}
+	checkpointer := &fakeCheckpointer{}
+	c := make(chan Data, 10)
+
+	err := getAllRepos(c, nil, source, pool, crawler.Filter{}, nil, checkpointer, storage.Checkpoint{})
+
+	assert.Nil(t, err)
+	assert.Len(t, checkpointer.saved, 1)
+	series := checkpointer.saved[0].Series
+	assert.Equal(t, stats.Total{Bytes: 10, Repos: 1}, series[storage.Created]["2015-03"]["Go"])
+	assert.Equal(t, stats.Total{Bytes: 10, Repos: 1}, series[storage.Pushed]["2020-06"]["Go"])
+}
+
+func TestGetAllRepos_reports_no_total_pages_for_a_search(t *testing.T) {
+	fakeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Link", `<`+r.URL.Path+`?page=10>; rel="last"`)
+		fmt.Fprint(w, `{"total_count": 1, "items": [{"full_name": "a/a", "created_at": "2015-03-01T00:00:00Z"}]}`)
+	}))
+	defer fakeServer.Close()
+	client, _ := github.NewEnterpriseClient(fakeServer.URL, nil)
+	client.HTTPClient = fakeServer.Client()
+	source := crawler.NewSearch(client, "", crawler.GitHubFounded, time.Now())
+	fetcher := fakeFetcher(func(repos []github.Repo) []crawler.Result {
+		return []crawler.Result{{Repo: repos[0], Languages: map[string]int{"Go": 10}}}
+	})
+	c := make(chan Data, 10)
+
+	err := getAllRepos(c, nil, source, fetcher, crawler.Filter{}, nil, nil, storage.Checkpoint{})
+
+	assert.Nil(t, err)
+	<-c
+	progress := <-c
+	assert.Equal(t, 1, progress.Pages)
+	assert.Equal(t, 0, progress.TotalPages)
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github_status/github"
+	"github_status/stats"
+)
+
+// metric is one family in the Prometheus text exposition format.
+type metric struct {
+	name    string
+	help    string
+	kind    string
+	samples []sample
+}
+
+type sample struct {
+	name   string   // suffix of the metric name, such as _sum
+	labels []string // name, value, name, value...
+	value  float64
+}
+
+func (m *metric) add(value float64, labels ...string) {
+	m.samples = append(m.samples, sample{labels: labels, value: value})
+}
+
+func (m *metric) write(w io.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n", m.name, m.help)
+	fmt.Fprintf(w, "# TYPE %s %s\n", m.name, m.kind)
+
+	lines := make([]string, 0, len(m.samples))
+	for _, s := range m.samples {
+		var labels []string
+		for i := 0; i+1 < len(s.labels); i += 2 {
+			labels = append(labels, fmt.Sprintf(`%s="%s"`, s.labels[i], escapeLabel(s.labels[i+1])))
+		}
+		line := m.name + s.name
+		if len(labels) > 0 {
+			line += "{" + strings.Join(labels, ",") + "}"
+		}
+		lines = append(lines, line+" "+strconv.FormatFloat(s.value, 'g', -1, 64))
+	}
+	sort.Strings(lines)
+	for _, line := range lines {
+		fmt.Fprintln(w, line)
+	}
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(value string) string {
+	return labelEscaper.Replace(value)
+}
+
+// writeMetrics exposes the state of the crawl and of client, which may be
+// nil, in the Prometheus text format.
+func writeMetrics(w io.Writer, snapshot *Snapshot, summary stats.Summary, client *github.Client, now time.Time) {
+	var metrics []*metric
+
+	if client != nil {
+		requests := &metric{name: "github_stats_requests_total", help: "GitHub API requests by endpoint and status.", kind: "counter"}
+		for key, count := range client.Metrics.Requests() {
+			requests.add(float64(count), "endpoint", key.Endpoint, "status", key.Status)
+		}
+		retries := &metric{name: "github_stats_retries_total", help: "GitHub API requests retried after a transient error.", kind: "counter"}
+		for endpoint, count := range client.Metrics.Retries() {
+			retries.add(float64(count), "endpoint", endpoint)
+		}
+
+		remaining := &metric{name: "github_stats_rate_limit_remaining", help: "Requests left in the rate limit window per token and resource.", kind: "gauge"}
+		reset := &metric{name: "github_stats_rate_limit_reset_seconds", help: "Seconds until the rate limit window resets per token and resource.", kind: "gauge"}
+		for resource, pool := range client.Tokens.Resources() {
+			for i, token := range pool.Tokens() {
+				// Never expose the token itself.
+				name := strconv.Itoa(i)
+				if token.Anonymous() {
+					name = "anonymous"
+				}
+				remaining.add(float64(token.RateLimitRemaining), "token", name, "resource", resource)
+				seconds := 0.0
+				if token.RateLimitReset.After(now) {
+					seconds = token.RateLimitReset.Sub(now).Seconds()
+				}
+				reset.add(seconds, "token", name, "resource", resource)
+			}
+		}
+		cost := &metric{name: "github_stats_graphql_cost_total", help: "Points the GraphQL API charged for the queries made.", kind: "counter"}
+		cost.add(float64(client.Metrics.GraphQLCost()))
+		metrics = append(metrics, requests, retries, cost, remaining, reset)
+	}
+
+	repos := &metric{name: "github_stats_repos_processed", help: "Repositories counted since the crawl started.", kind: "gauge"}
+	repos.add(float64(snapshot.Repos))
+	pages := &metric{name: "github_stats_pages_processed", help: "Pages crawled since the crawl started.", kind: "gauge"}
+	pages.add(float64(snapshot.Pages))
+	filtered := &metric{name: "github_stats_repos_filtered", help: "Repositories left out by reason.", kind: "gauge"}
+	for reason, count := range snapshot.Filtered {
+		filtered.add(float64(count), "reason", reason)
+	}
+	updated := &metric{name: "github_stats_last_update_timestamp_seconds", help: "Unix time of the last update from the crawl.", kind: "gauge"}
+	updated.add(float64(snapshot.UpdatedAt.Unix()))
+	languages := &metric{name: "github_stats_language_bytes", help: "Bytes of code per language.", kind: "gauge"}
+	for language, bytes := range snapshot.Languages {
+		languages.add(float64(bytes), "language", language)
+	}
+	languageRepos := &metric{name: "github_stats_language_repos", help: "Repositories using each language.", kind: "gauge"}
+	for language, count := range snapshot.LanguageRepos {
+		languageRepos.add(float64(count), "language", language)
+	}
+	weighted := &metric{name: "github_stats_language_weighted_share", help: "Share of each language in this crawl under each weighting.", kind: "gauge"}
+	for weighting, weights := range snapshot.Weighted {
+		total := 0.0
+		for _, weight := range weights {
+			total += weight
+		}
+		for language, weight := range weights {
+			if total > 0 {
+				weighted.add(weight/total, "language", language, "weighting", string(weighting))
+			}
+		}
+	}
+	metrics = append(metrics, repos, pages, filtered, updated, languages, languageRepos, weighted)
+
+	sizes := &metric{name: "github_stats_language_repo_bytes", help: "Bytes of each language per repository using it, from a sample of this crawl.", kind: "summary"}
+	shares := &metric{name: "github_stats_language_byte_share", help: "Share of bytes per language with its 95% confidence interval.", kind: "gauge"}
+	for language, s := range summary.Languages {
+		sizes.add(s.Median, "language", language, "quantile", "0.5")
+		sizes.add(s.P90, "language", language, "quantile", "0.9")
+		sizes.add(s.P99, "language", language, "quantile", "0.99")
+		sizes.samples = append(sizes.samples, sample{name: "_sum", labels: []string{"language", language}, value: float64(s.Bytes)})
+		sizes.samples = append(sizes.samples, sample{name: "_count", labels: []string{"language", language}, value: float64(s.Repos)})
+		shares.add(s.ByteShare, "language", language, "bound", "estimate")
+		shares.add(s.ByteShareCI.Low, "language", language, "bound", "low")
+		shares.add(s.ByteShareCI.High, "language", language, "bound", "high")
+	}
+	metrics = append(metrics, sizes, shares)
+
+	for _, m := range metrics {
+		m.write(w)
+	}
+}
+
+func (s *Server) metrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	writeMetrics(w, s.Aggregator.Snapshot(), s.Aggregator.Stats(), s.Client, time.Now())
+}
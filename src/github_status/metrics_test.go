@@ -0,0 +1,47 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github_status/github"
+	"github_status/stats"
+)
+
+func TestWriteMetrics_exposes_the_crawl_in_prometheus_format(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	snapshot := &Snapshot{
+		Languages: map[string]int{"Go": 100, `C"quoted`: 5},
+		Repos:     7,
+		Pages:     2,
+		Filtered:  map[string]int{"fork": 3},
+		UpdatedAt: now,
+	}
+	client := github.NewClient(github.NewTokenPool("secret"))
+
+	var out bytes.Buffer
+	writeMetrics(&out, snapshot, stats.Summary{}, client, now)
+	metrics := out.String()
+
+	assert.Contains(t, metrics, "# TYPE github_stats_requests_total counter\n")
+	assert.Contains(t, metrics, "# TYPE github_stats_language_bytes gauge\n")
+	assert.Contains(t, metrics, "github_stats_repos_processed 7\n")
+	assert.Contains(t, metrics, "github_stats_pages_processed 2\n")
+	assert.Contains(t, metrics, `github_stats_repos_filtered{reason="fork"} 3`+"\n")
+	assert.Contains(t, metrics, `github_stats_language_bytes{language="Go"} 100`+"\n")
+	assert.Contains(t, metrics, `github_stats_language_bytes{language="C\"quoted"} 5`+"\n")
+	assert.Contains(t, metrics, `github_stats_rate_limit_remaining{token="0",resource="core"} 5000`+"\n")
+	assert.Contains(t, metrics, "github_stats_last_update_timestamp_seconds 1.7e+09\n")
+	assert.NotContains(t, metrics, "secret")
+}
+
+func TestWriteMetrics_without_a_client_only_exposes_the_crawl(t *testing.T) {
+	var out bytes.Buffer
+	writeMetrics(&out, NewAggregator().Snapshot(), stats.Summary{}, nil, time.Now())
+
+	assert.False(t, strings.Contains(out.String(), "github_stats_requests_total"))
+	assert.Contains(t, out.String(), "github_stats_repos_processed 0\n")
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+
+	"github_status/stats"
+	"github_status/storage"
+)
+
+// seriesReport prints language shares over time, as a table followed by the
+// biggest movers between two buckets or as CSV.
+type seriesReport struct {
+	Period stats.Period
+	// From and To are the buckets to compare, the first and last by default.
+	From string
+	To   string
+	Top  int
+	CSV  bool
+	// Normalize is applied to the languages of every bucket, like
+	// Aggregator.Normalize.
+	Normalize func(map[string]int) map[string]int
+}
+
+// runSeries prints the series by date kept in MongoDB when mongo is set and
+// in the checkpoint otherwise.
+func runSeries(mongo, database, checkpointPath, date string, report seriesReport) int {
+	if date != storage.Created && date != storage.Pushed {
+		fmt.Fprintf(os.Stderr, "invalid -date %q, want created or pushed\n", date)
+		return exitUsage
+	}
+
+	var store *storage.Store
+	var err error
+	if mongo != "" {
+		if store, err = storage.Dial(mongo, database); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitFailed
+		}
+		defer store.Close()
+	}
+	series, err := loadSeries(store, storage.FileCheckpointer{Path: checkpointPath}, date)
+	if err == nil {
+		err = report.write(os.Stdout, series)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitFailed
+	}
+	return exitDone
+}
+
+// loadSeries reads the monthly totals by date from the store when there is
+// one and from the checkpoint otherwise.
+func loadSeries(store *storage.Store, checkpointer storage.Checkpointer, date string) (stats.Series, error) {
+	if store != nil {
+		return store.LanguageSeries(date)
+	}
+	checkpoint, _, err := checkpointer.Load()
+	if err != nil {
+		return nil, err
+	}
+	if checkpoint.Series[date] == nil {
+		return make(stats.Series), nil
+	}
+	return checkpoint.Series[date], nil
+}
+
+func (r seriesReport) write(w io.Writer, series stats.Series) error {
+	series = normalizeSeries(series.By(r.Period), r.Normalize)
+	if r.CSV {
+		return writeSeriesCSV(w, series)
+	}
+
+	buckets := series.Buckets()
+	if len(buckets) == 0 {
+		fmt.Fprintln(w, "no repos with that date counted yet")
+		return nil
+	}
+	from, to := r.From, r.To
+	if from == "" {
+		from = buckets[0]
+	}
+	if to == "" {
+		to = buckets[len(buckets)-1]
+	}
+	for _, bucket := range []string{from, to} {
+		if series[bucket] == nil {
+			return fmt.Errorf("no repos counted in %s, buckets run from %s to %s", bucket, buckets[0], buckets[len(buckets)-1])
+		}
+	}
+
+	writeSeriesTable(w, series, r.Top)
+	if from != to {
+		fmt.Fprintf(w, "\nBiggest movers from %s to %s:\n", from, to)
+		writeMovers(w, series.Movers(from, to, r.Top))
+	}
+	return nil
+}
+
+// normalizeSeries applies normalize to every bucket of series. Repos of
+// languages it merges are added up, as in Aggregator.Seed.
+func normalizeSeries(series stats.Series, normalize func(map[string]int) map[string]int) stats.Series {
+	if normalize == nil {
+		return series
+	}
+	normalized := make(stats.Series)
+	for bucket, totals := range series {
+		bytes := make(map[string]int, len(totals))
+		repos := make(map[string]int, len(totals))
+		for lang, total := range totals {
+			bytes[lang], repos[lang] = int(total.Bytes), total.Repos
+		}
+		bytes, repos = normalize(bytes), normalize(repos)
+		for lang, b := range bytes {
+			normalized.AddTotal(bucket, lang, stats.Total{Bytes: int64(b), Repos: repos[lang]})
+		}
+	}
+	return normalized
+}
+
+// seriesLanguages are the top languages by bytes over all of series.
+func seriesLanguages(series stats.Series, top int) []string {
+	bytes := make(map[string]int64)
+	for _, totals := range series {
+		for lang, total := range totals {
+			bytes[lang] += total.Bytes
+		}
+	}
+	names := make([]string, 0, len(bytes))
+	for name := range bytes {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if bytes[names[i]] != bytes[names[j]] {
+			return bytes[names[i]] > bytes[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	if top > 0 && len(names) > top {
+		names = names[:top]
+	}
+	return names
+}
+
+// writeSeriesTable writes a row of byte shares of the top languages per
+// bucket.
+func writeSeriesTable(w io.Writer, series stats.Series, top int) {
+	languages := seriesLanguages(series, top)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprint(tw, "Period\t")
+	for _, lang := range languages {
+		fmt.Fprintf(tw, "%s\t", lang)
+	}
+	fmt.Fprintln(tw)
+	for _, bucket := range series.Buckets() {
+		shares := series.Shares(bucket)
+		fmt.Fprintf(tw, "%s\t", bucket)
+		for _, lang := range languages {
+			fmt.Fprintf(tw, "%.1f%%\t", shares[lang]*100)
+		}
+		fmt.Fprintln(tw)
+	}
+	tw.Flush()
+}
+
+func writeMovers(w io.Writer, movers []stats.Mover) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	for _, m := range movers {
+		fmt.Fprintf(tw, "%s\t%.1f%%\t→ %.1f%%\t%+.1f pts\t\n", m.Language, m.From*100, m.To*100, m.Change()*100)
+	}
+	tw.Flush()
+}
+
+// writeSeriesCSV writes a row per bucket and language, buckets in order and
+// languages by bytes.
+func writeSeriesCSV(w io.Writer, series stats.Series) error {
+	out := csv.NewWriter(w)
+	out.Write([]string{"period", "language", "bytes", "repos", "share"})
+	for _, bucket := range series.Buckets() {
+		shares := series.Shares(bucket)
+		totals := series[bucket]
+		for _, lang := range seriesLanguages(stats.Series{bucket: totals}, 0) {
+			out.Write([]string{
+				bucket,
+				lang,
+				strconv.FormatInt(totals[lang].Bytes, 10),
+				strconv.Itoa(totals[lang].Repos),
+				strconv.FormatFloat(shares[lang], 'f', 6, 64),
+			})
+		}
+	}
+	out.Flush()
+	return out.Error()
+}
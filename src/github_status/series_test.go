@@ -0,0 +1,51 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github_status/stats"
+)
+
+var testSeries = stats.Series{
+	"2018-02": {"Go": {Bytes: 60, Repos: 2}, "Perl": {Bytes: 40, Repos: 1}},
+	"2024-07": {"Go": {Bytes: 50, Repos: 2}, "Rust": {Bytes: 30, Repos: 1}, "Perl": {Bytes: 20, Repos: 1}},
+}
+
+func TestSeriesReport_writes_shares_per_bucket_and_the_movers(t *testing.T) {
+	var out bytes.Buffer
+	err := seriesReport{Period: stats.Year, Top: 2}.write(&out, testSeries)
+	lines := strings.Split(strings.TrimRight(out.String(), "\n"), "\n")
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"Period", "Go", "Perl"}, strings.Fields(lines[0]))
+	assert.Equal(t, []string{"2018", "60.0%", "40.0%"}, strings.Fields(lines[1]))
+	assert.Equal(t, []string{"2024", "50.0%", "20.0%"}, strings.Fields(lines[2]))
+	assert.Equal(t, "Biggest movers from 2018 to 2024:", lines[4])
+	assert.Equal(t, []string{"Rust", "0.0%", "→", "30.0%", "+30.0", "pts"}, strings.Fields(lines[5]))
+	assert.Equal(t, []string{"Perl", "40.0%", "→", "20.0%", "-20.0", "pts"}, strings.Fields(lines[6]))
+}
+
+func TestSeriesReport_rejects_buckets_without_repos(t *testing.T) {
+	err := seriesReport{Period: stats.Year, From: "2019"}.write(&bytes.Buffer{}, testSeries)
+
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "from 2018 to 2024")
+}
+
+func TestSeriesReport_writes_csv(t *testing.T) {
+	var out bytes.Buffer
+	normalize := func(languages map[string]int) map[string]int {
+		delete(languages, "Perl")
+		return languages
+	}
+	err := seriesReport{Period: stats.Quarter, CSV: true, Normalize: normalize}.write(&out, testSeries)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "period,language,bytes,repos,share\n"+
+		"2018-Q1,Go,60,2,1.000000\n"+
+		"2024-Q3,Go,50,2,0.625000\n"+
+		"2024-Q3,Rust,30,1,0.375000\n", out.String())
+}
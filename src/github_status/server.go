@@ -0,0 +1,120 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github_status/github"
+	"github_status/stats"
+	"github_status/storage"
+)
+
+// RepoFinder looks up the stored languages of a repository; *storage.Store
+// is one.
+type RepoFinder interface {
+	Repo(fullName string) (storage.RepoLanguages, error)
+}
+
+// Server exposes the live crawl over HTTP:
+//
+//	/stats                 the current Snapshot and its stats as JSON
+//	/stats/stream          a Server-Sent Event with the same on every update
+//	/repos/{owner}/{name}  the stored languages of one repository
+//	/healthz               200 while the process is up
+//	/metrics               Prometheus metrics of the crawl and the client
+type Server struct {
+	Aggregator *Aggregator
+	// Repos is nil when the crawl is not stored.
+	Repos  RepoFinder
+	Client *github.Client
+}
+
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/stats", s.stats)
+	mux.HandleFunc("/stats/stream", s.stream)
+	mux.HandleFunc("/repos/", s.repo)
+	mux.HandleFunc("/healthz", s.healthz)
+	mux.HandleFunc("/metrics", s.metrics)
+	return mux
+}
+
+// statsResponse is a Snapshot with the distributions of its languages.
+type statsResponse struct {
+	*Snapshot
+	Stats stats.Summary `json:"stats"`
+}
+
+func (s *Server) stats(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, statsResponse{s.Aggregator.Snapshot(), s.Aggregator.Stats()})
+}
+
+func (s *Server) stream(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+	updates, cancel := s.Aggregator.Subscribe()
+	defer cancel()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	snapshot := s.Aggregator.Snapshot()
+	for {
+		data, err := json.Marshal(statsResponse{snapshot, s.Aggregator.Stats()})
+		if err != nil {
+			return
+		}
+		if _, err := fmt.Fprintf(w, "event: stats\ndata: %s\n\n", data); err != nil {
+			return
+		}
+		flusher.Flush()
+
+		select {
+		case <-r.Context().Done():
+			return
+		case snapshot, ok = <-updates:
+			if !ok {
+				return
+			}
+		}
+	}
+}
+
+func (s *Server) repo(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/repos/"), "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		http.NotFound(w, r)
+		return
+	}
+	if s.Repos == nil {
+		http.Error(w, "repositories are only kept when crawling with -mongo", http.StatusNotFound)
+		return
+	}
+
+	repo, err := s.Repos.Repo(parts[0] + "/" + parts[1])
+	if err == storage.ErrNotFound {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, repo)
+}
+
+func (s *Server) healthz(w http.ResponseWriter, r *http.Request) {
+	fmt.Fprintln(w, "ok")
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github_status/stats"
+	"github_status/storage"
+)
+
+type fakeRepos map[string]storage.RepoLanguages
+
+func (f fakeRepos) Repo(fullName string) (storage.RepoLanguages, error) {
+	repo, ok := f[fullName]
+	if !ok {
+		return repo, storage.ErrNotFound
+	}
+	return repo, nil
+}
+
+func TestServer_stats_returns_the_snapshot_as_json(t *testing.T) {
+	aggregator := NewAggregator()
+	aggregator.Add(Data{Repo: repo("a/a", map[string]int{"Go": 10})})
+	fakeServer := httptest.NewServer((&Server{Aggregator: aggregator}).Handler())
+	defer fakeServer.Close()
+
+	resp, err := http.Get(fakeServer.URL + "/stats")
+	assert.Nil(t, err)
+	defer resp.Body.Close()
+
+	var snapshot struct {
+		Snapshot
+		Stats stats.Summary `json:"stats"`
+	}
+	assert.Nil(t, json.NewDecoder(resp.Body).Decode(&snapshot))
+	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+	assert.Equal(t, map[string]int{"Go": 10}, snapshot.Languages)
+	assert.Equal(t, 1, snapshot.Repos)
+	assert.Equal(t, 1.0, snapshot.Stats.Languages["Go"].RepoShare)
+}
+
+func TestServer_stream_pushes_a_snapshot_on_every_update(t *testing.T) {
+	aggregator := NewAggregator()
+	fakeServer := httptest.NewServer((&Server{Aggregator: aggregator}).Handler())
+	defer fakeServer.Close()
+
+	resp, err := http.Get(fakeServer.URL + "/stats/stream")
+	assert.Nil(t, err)
+	defer resp.Body.Close()
+	events := bufio.NewReader(resp.Body)
+
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+	assert.Equal(t, 0, nextEvent(t, events).Repos)
+
+	aggregator.Add(Data{Repo: repo("a/a", map[string]int{"Go": 10})})
+
+	assert.Equal(t, 1, nextEvent(t, events).Repos)
+}
+
+func nextEvent(t *testing.T, events *bufio.Reader) Snapshot {
+	var snapshot Snapshot
+	for {
+		line, err := events.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.HasPrefix(line, "data: ") {
+			assert.Nil(t, json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &snapshot))
+			return snapshot
+		}
+	}
+}
+
+func TestServer_repos_returns_the_stored_languages(t *testing.T) {
+	server := &Server{Aggregator: NewAggregator(), Repos: fakeRepos{
+		"golang/go": {FullName: "golang/go", Languages: map[string]int{"Go": 100}},
+	}}
+	fakeServer := httptest.NewServer(server.Handler())
+	defer fakeServer.Close()
+
+	resp, err := http.Get(fakeServer.URL + "/repos/golang/go")
+	assert.Nil(t, err)
+	defer resp.Body.Close()
+
+	var repo storage.RepoLanguages
+	assert.Nil(t, json.NewDecoder(resp.Body).Decode(&repo))
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, map[string]int{"Go": 100}, repo.Languages)
+}
+
+func TestServer_repos_is_not_found_for_unknown_repos_or_without_a_store(t *testing.T) {
+	for _, server := range []*Server{
+		{Aggregator: NewAggregator(), Repos: fakeRepos{}},
+		{Aggregator: NewAggregator()},
+	} {
+		fakeServer := httptest.NewServer(server.Handler())
+		for _, path := range []string{"/repos/golang/go", "/repos/golang", "/repos/a/b/c"} {
+			resp, err := http.Get(fakeServer.URL + path)
+			assert.Nil(t, err)
+			resp.Body.Close()
+			assert.Equal(t, http.StatusNotFound, resp.StatusCode, path)
+		}
+		fakeServer.Close()
+	}
+}
+
+func TestServer_healthz_is_ok(t *testing.T) {
+	fakeServer := httptest.NewServer((&Server{Aggregator: NewAggregator()}).Handler())
+	defer fakeServer.Close()
+
+	resp, err := http.Get(fakeServer.URL + "/healthz")
+	assert.Nil(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github_status/stats"
+)
+
+// Exit codes. A crawl stopped by a signal exits with 128 plus the signal
+// number, as shells report it.
+const (
+	exitDone   = 0
+	exitFailed = 1
+	exitUsage  = 2
+)
+
+func signalExitCode(sig os.Signal) int {
+	if s, ok := sig.(syscall.Signal); ok {
+		return 128 + int(s)
+	}
+	return exitFailed
+}
+
+// Shutdown closes Stop on the first SIGINT or SIGTERM so the crawl stops once
+// the requests in flight return, keeping the checkpoint of the last whole
+// page, and exits at once on the second.
+type Shutdown struct {
+	Stop chan struct{}
+
+	mutex  sync.Mutex
+	signal os.Signal
+}
+
+func NotifyShutdown() *Shutdown {
+	s := &Shutdown{Stop: make(chan struct{})}
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go s.wait(signals, os.Exit)
+	return s
+}
+
+func (s *Shutdown) wait(signals <-chan os.Signal, exit func(int)) {
+	sig := <-signals
+	s.mutex.Lock()
+	s.signal = sig
+	s.mutex.Unlock()
+	fmt.Fprintf(os.Stderr, "\n%v: stopping after the requests in flight, again to quit now\n", sig)
+	close(s.Stop)
+
+	exit(signalExitCode(<-signals))
+}
+
+// Signal is the signal that stopped the crawl, or nil.
+func (s *Shutdown) Signal() os.Signal {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.signal
+}
+
+// report summarizes a crawl that is over: how it ended, what it covered, the
+// top languages, their distributions and their shares under every weighting.
+func report(w io.Writer, snapshot *Snapshot, summary stats.Summary, ended string, now time.Time) {
+	elapsed := now.Sub(snapshot.StartedAt).Round(time.Second)
+	fmt.Fprintf(w, "Crawl %s after %v: %d repos (%.1f/min), %d pages\n", ended, elapsed, snapshot.Repos, throughput(snapshot, now), snapshot.Pages)
+	for _, line := range languageRows(snapshot.Languages, nil, DefaultTopLanguages+1, defaultWidth) {
+		fmt.Fprintln(w, line)
+	}
+	if len(summary.Languages) > 0 {
+		fmt.Fprintln(w)
+		writeStatsTable(w, summary, DefaultTopLanguages)
+	}
+	if len(snapshot.Weighted[stats.Raw]) > 0 {
+		fmt.Fprintln(w)
+		writeWeightingTable(w, snapshot.Weighted, DefaultTopLanguages)
+	}
+	if snapshot.Sample != nil && snapshot.Sample.Pages > 0 {
+		fmt.Fprintln(w)
+		writeSampleTable(w, *snapshot.Sample, summary, DefaultTopLanguages)
+	}
+}
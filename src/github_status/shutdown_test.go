@@ -0,0 +1,51 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github_status/stats"
+)
+
+func TestSignalExitCode_follows_the_shell_convention(t *testing.T) {
+	assert.Equal(t, 130, signalExitCode(os.Interrupt))
+	assert.Equal(t, 143, signalExitCode(syscall.SIGTERM))
+}
+
+func TestShutdown_stops_on_the_first_signal_and_exits_on_the_second(t *testing.T) {
+	signals := make(chan os.Signal, 2)
+	exited := make(chan int, 1)
+	shutdown := &Shutdown{Stop: make(chan struct{})}
+	go shutdown.wait(signals, func(code int) { exited <- code })
+
+	signals <- syscall.SIGTERM
+	<-shutdown.Stop
+	assert.Equal(t, syscall.SIGTERM, shutdown.Signal())
+	assert.Equal(t, 0, len(exited))
+
+	signals <- os.Interrupt
+	assert.Equal(t, 130, <-exited)
+}
+
+func TestReport_summarizes_the_crawl(t *testing.T) {
+	now := time.Now()
+	snapshot := &Snapshot{
+		Languages: map[string]int{"Go": 3, "C": 1},
+		Repos:     20,
+		Pages:     2,
+		StartedAt: now.Add(-10 * time.Minute),
+	}
+
+	var out bytes.Buffer
+	report(&out, snapshot, stats.Summary{}, "interrupted", now)
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+
+	assert.Equal(t, "Crawl interrupted after 10m0s: 20 repos (2.0/min), 2 pages", lines[0])
+	assert.Len(t, lines, 3)
+	assert.True(t, strings.HasSuffix(lines[1], " 75.0%"))
+}
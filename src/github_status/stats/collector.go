@@ -0,0 +1,253 @@
+package stats
+
+import (
+	"math/rand"
+	"sort"
+)
+
+const (
+	DefaultSampleSize = 10000
+	DefaultResamples  = 200
+	ConfidenceLevel   = 0.95
+)
+
+// LanguageStats describes one language. ByteShare is its part of all bytes,
+// RepoShare the part of repos that use it at all, so repo shares add up to
+// more than 1. Mean, Median, P90, P99 and Histogram are about the bytes of the
+// language in the repos that use it.
+type LanguageStats struct {
+	Bytes       int64    `json:"bytes"`
+	Repos       int      `json:"repos"`
+	ByteShare   float64  `json:"byte_share"`
+	RepoShare   float64  `json:"repo_share"`
+	ByteShareCI Interval `json:"byte_share_ci"`
+	RepoShareCI Interval `json:"repo_share_ci"`
+	Mean        float64  `json:"mean"`
+	Median      float64  `json:"median"`
+	P90         float64  `json:"p90"`
+	P99         float64  `json:"p99"`
+	Histogram   []Bucket `json:"histogram"`
+}
+
+type Summary struct {
+	Repos int `json:"repos"`
+	// Sampled is the number of repos percentiles and intervals come from.
+	Sampled   int                      `json:"sampled"`
+	Languages map[string]LanguageStats `json:"languages"`
+}
+
+type language struct {
+	bytes   int64
+	repos   int
+	buckets map[int]int
+}
+
+type repo struct {
+	name      string
+	languages map[string]int
+}
+
+// Collector accumulates the language maps of repos. Totals, repo counts and
+// histograms cover every repo; percentiles and confidence intervals come from
+// a uniform sample of SampleSize repos so memory stays bounded however long
+// the crawl runs. A Collector is not safe for concurrent use.
+type Collector struct {
+	SampleSize int
+	Resamples  int
+
+	rand      *rand.Rand
+	repos     int
+	bytes     int64
+	languages map[string]*language
+	sample    []repo
+	sampled   map[string]int
+	offered   int
+}
+
+func NewCollector(seed int64) *Collector {
+	return &Collector{
+		SampleSize: DefaultSampleSize,
+		Resamples:  DefaultResamples,
+		rand:       rand.New(rand.NewSource(seed)),
+		languages:  make(map[string]*language),
+		sampled:    make(map[string]int),
+	}
+}
+
+func (c *Collector) Add(name string, languages map[string]int) {
+	c.repos++
+	kept := make(map[string]int, len(languages))
+	for lang, bytes := range languages {
+		if bytes <= 0 {
+			continue
+		}
+		kept[lang] = bytes
+		l, ok := c.languages[lang]
+		if !ok {
+			l = &language{buckets: make(map[int]int)}
+			c.languages[lang] = l
+		}
+		l.bytes += int64(bytes)
+		l.repos++
+		l.buckets[logBucket(int64(bytes))]++
+		c.bytes += int64(bytes)
+	}
+	c.offer(repo{name: name, languages: kept})
+}
+
+// Remove takes back a repo added before with the same languages, such as
+// when it is crawled again.
+func (c *Collector) Remove(name string, languages map[string]int) {
+	c.repos--
+	for lang, bytes := range languages {
+		l, ok := c.languages[lang]
+		if bytes <= 0 || !ok {
+			continue
+		}
+		l.bytes -= int64(bytes)
+		l.repos--
+		l.buckets[logBucket(int64(bytes))]--
+		c.bytes -= int64(bytes)
+		if l.repos <= 0 {
+			delete(c.languages, lang)
+		}
+	}
+
+	if i, ok := c.sampled[name]; ok {
+		last := len(c.sample) - 1
+		c.sample[i] = c.sample[last]
+		c.sampled[c.sample[i].name] = i
+		c.sample = c.sample[:last]
+		delete(c.sampled, name)
+	}
+}
+
+// offer keeps r in the sample with the probability that leaves every repo
+// offered so far equally likely to be in it (reservoir sampling).
+func (c *Collector) offer(r repo) {
+	c.offered++
+	if len(c.sample) < c.SampleSize {
+		c.sampled[r.name] = len(c.sample)
+		c.sample = append(c.sample, r)
+		return
+	}
+	if i := c.rand.Intn(c.offered); i < len(c.sample) {
+		delete(c.sampled, c.sample[i].name)
+		c.sample[i] = r
+		c.sampled[r.name] = i
+	}
+}
+
+// Snapshot copies what Summary needs, so the bootstrap can run without
+// holding whatever guards the collector.
+func (c *Collector) Snapshot() *Snapshot {
+	languages := make(map[string]language, len(c.languages))
+	for name, l := range c.languages {
+		buckets := make(map[int]int, len(l.buckets))
+		for b, n := range l.buckets {
+			buckets[b] = n
+		}
+		languages[name] = language{bytes: l.bytes, repos: l.repos, buckets: buckets}
+	}
+	return &Snapshot{
+		resamples: c.Resamples,
+		rand:      rand.New(rand.NewSource(c.rand.Int63())),
+		repos:     c.repos,
+		bytes:     c.bytes,
+		languages: languages,
+		// The language maps of sampled repos are never changed, only replaced.
+		sample: append([]repo(nil), c.sample...),
+	}
+}
+
+func (c *Collector) Summary() Summary {
+	return c.Snapshot().Summary()
+}
+
+// Snapshot is a copy of a Collector at one point.
+type Snapshot struct {
+	resamples int
+	rand      *rand.Rand
+	repos     int
+	bytes     int64
+	languages map[string]language
+	sample    []repo
+}
+
+func (s *Snapshot) Summary() Summary {
+	summary := Summary{Repos: s.repos, Sampled: len(s.sample), Languages: make(map[string]LanguageStats, len(s.languages))}
+
+	sizes := make(map[string][]float64)
+	for _, r := range s.sample {
+		for lang, bytes := range r.languages {
+			sizes[lang] = append(sizes[lang], float64(bytes))
+		}
+	}
+	index, intervals := s.shares()
+
+	for name, l := range s.languages {
+		ls := LanguageStats{
+			Bytes:     l.bytes,
+			Repos:     l.repos,
+			Mean:      float64(l.bytes) / float64(l.repos),
+			Histogram: histogram(l.buckets),
+		}
+		if i, ok := index[name]; ok && intervals != nil {
+			ls.ByteShareCI, ls.RepoShareCI = intervals[i], intervals[len(index)+i]
+		}
+		if s.bytes > 0 {
+			ls.ByteShare = float64(l.bytes) / float64(s.bytes)
+		}
+		if s.repos > 0 {
+			ls.RepoShare = float64(l.repos) / float64(s.repos)
+		}
+		sorted := sizes[name]
+		sort.Float64s(sorted)
+		ls.Median, ls.P90, ls.P99 = Percentile(sorted, 50), Percentile(sorted, 90), Percentile(sorted, 99)
+		summary.Languages[name] = ls
+	}
+	return summary
+}
+
+// shares bootstraps the byte and repo shares of every language in the
+// sample. The intervals of the language at index[lang] are at that position
+// for bytes and len(index) further for repos.
+func (s *Snapshot) shares() (map[string]int, []Interval) {
+	// Flatten the sample so a resample only adds up slices: the languages of
+	// repo i are langs[start[i]:start[i+1]].
+	index := make(map[string]int)
+	start := make([]int, len(s.sample)+1)
+	var langs []int
+	var bytes []float64
+	for i, r := range s.sample {
+		for lang, b := range r.languages {
+			l, ok := index[lang]
+			if !ok {
+				l = len(index)
+				index[lang] = l
+			}
+			langs = append(langs, l)
+			bytes = append(bytes, float64(b))
+		}
+		start[i+1] = len(langs)
+	}
+
+	n := len(index)
+	intervals := Bootstrap(s.rand, len(s.sample), 2*n, s.resamples, ConfidenceLevel, func(indexes []int, shares []float64) {
+		total := 0.0
+		for _, i := range indexes {
+			for j := start[i]; j < start[i+1]; j++ {
+				shares[langs[j]] += bytes[j]
+				shares[n+langs[j]]++
+				total += bytes[j]
+			}
+		}
+		for l := 0; l < n; l++ {
+			if total > 0 {
+				shares[l] /= total
+			}
+			shares[n+l] /= float64(len(indexes))
+		}
+	})
+	return index, intervals
+}
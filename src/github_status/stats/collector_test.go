@@ -0,0 +1,79 @@
+package stats
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollector_Summary_compares_byte_and_repo_shares(t *testing.T) {
+	c := NewCollector(1)
+	c.Add("a/a", map[string]int{"C": 900, "Go": 10})
+	c.Add("b/b", map[string]int{"Go": 30})
+	c.Add("c/c", map[string]int{"Go": 50})
+
+	summary := c.Summary()
+	golang := summary.Languages["Go"]
+
+	assert.Equal(t, 3, summary.Repos)
+	assert.Equal(t, 3, summary.Sampled)
+	assert.Equal(t, int64(90), golang.Bytes)
+	assert.Equal(t, 3, golang.Repos)
+	assert.InDelta(t, 90.0/990, golang.ByteShare, 1e-9)
+	assert.InDelta(t, 1.0, golang.RepoShare, 1e-9)
+	assert.InDelta(t, 1.0/3, summary.Languages["C"].RepoShare, 1e-9)
+	assert.Equal(t, 30.0, golang.Mean)
+	assert.Equal(t, 30.0, golang.Median)
+	assert.Equal(t, []Bucket{{Min: 8, Max: 16, Count: 1}, {Min: 16, Max: 32, Count: 1}, {Min: 32, Max: 64, Count: 1}}, golang.Histogram)
+	assert.True(t, golang.ByteShareCI.Low <= golang.ByteShare)
+	assert.True(t, golang.ByteShareCI.High >= golang.ByteShare)
+}
+
+func TestCollector_Remove_takes_a_repo_back(t *testing.T) {
+	c := NewCollector(1)
+	c.Add("a/a", map[string]int{"Go": 10})
+	c.Add("b/b", map[string]int{"C": 10})
+
+	c.Remove("a/a", map[string]int{"Go": 10})
+
+	summary := c.Summary()
+	assert.Equal(t, 1, summary.Repos)
+	assert.Equal(t, 1, summary.Sampled)
+	_, ok := summary.Languages["Go"]
+	assert.False(t, ok)
+}
+
+func TestCollector_keeps_a_bounded_sample(t *testing.T) {
+	c := NewCollector(1)
+	c.SampleSize = 50
+	c.Resamples = 10
+	for i := 0; i < 1000; i++ {
+		c.Add(fmt.Sprintf("o/r%d", i), map[string]int{"Go": i + 1})
+	}
+
+	summary := c.Summary()
+
+	assert.Equal(t, 1000, summary.Repos)
+	assert.Equal(t, 50, summary.Sampled)
+	assert.Equal(t, int64(500500), summary.Languages["Go"].Bytes)
+	// The median of a uniform sample of 1..1000 lands well inside the range.
+	assert.True(t, summary.Languages["Go"].Median > 200)
+	assert.True(t, summary.Languages["Go"].Median < 800)
+}
+
+func TestCollector_snapshot_is_not_changed_by_later_repos(t *testing.T) {
+	c := NewCollector(1)
+	c.Add("a/a", map[string]int{"Go": 10})
+	snapshot := c.Snapshot()
+
+	c.Add("b/b", map[string]int{"Go": 30, "C": 10})
+	c.Remove("a/a", map[string]int{"Go": 10})
+
+	summary := snapshot.Summary()
+	assert.Equal(t, 1, summary.Repos)
+	assert.Equal(t, int64(10), summary.Languages["Go"].Bytes)
+	assert.Equal(t, []Bucket{{Min: 8, Max: 16, Count: 1}}, summary.Languages["Go"].Histogram)
+	_, ok := summary.Languages["C"]
+	assert.False(t, ok)
+}
@@ -0,0 +1,163 @@
+package stats
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Period is how wide the buckets of a Series are.
+type Period string
+
+const (
+	Month   Period = "month"
+	Quarter Period = "quarter"
+	Year    Period = "year"
+)
+
+// monthLayout is the format of the months a Series is kept in.
+const monthLayout = "2006-01"
+
+func ParsePeriod(name string) (Period, error) {
+	switch p := Period(strings.ToLower(strings.TrimSpace(name))); p {
+	case Month, Quarter, Year:
+		return p, nil
+	}
+	return "", fmt.Errorf("unknown period %q, want month, quarter or year", name)
+}
+
+// Bucket names the bucket of p that month, such as 2018-03, falls in: 2018-03,
+// 2018-Q1 or 2018.
+func (p Period) Bucket(month string) string {
+	t, err := time.Parse(monthLayout, month)
+	if err != nil {
+		return month
+	}
+	switch p {
+	case Quarter:
+		return fmt.Sprintf("%d-Q%d", t.Year(), (int(t.Month())+2)/3)
+	case Year:
+		return t.Format("2006")
+	}
+	return month
+}
+
+type Total struct {
+	Bytes int64 `json:"bytes"`
+	Repos int   `json:"repos"`
+}
+
+// Series totals languages by the month of a date of their repos, such as
+// when they were created, from which wider periods are summed up.
+type Series map[string]map[string]Total
+
+// Add counts languages in the month of t, or takes them back with a sign of
+// -1. Repos without the date are left out.
+func (s Series) Add(t time.Time, languages map[string]int, sign int) {
+	if t.IsZero() {
+		return
+	}
+	month := t.UTC().Format(monthLayout)
+	for lang, bytes := range languages {
+		if bytes > 0 {
+			s.AddTotal(month, lang, Total{Bytes: int64(sign * bytes), Repos: sign})
+		}
+	}
+}
+
+// AddTotal adds total to a language in a bucket, such as one counted by a
+// store.
+func (s Series) AddTotal(bucket, lang string, total Total) {
+	totals := s[bucket]
+	if totals == nil {
+		totals = make(map[string]Total)
+		s[bucket] = totals
+	}
+	t := totals[lang]
+	t.Bytes += total.Bytes
+	t.Repos += total.Repos
+	if t.Bytes == 0 && t.Repos == 0 {
+		delete(totals, lang)
+		if len(totals) == 0 {
+			delete(s, bucket)
+		}
+		return
+	}
+	totals[lang] = t
+}
+
+// By sums the months of s up into buckets of p.
+func (s Series) By(p Period) Series {
+	grouped := make(Series)
+	for month, totals := range s {
+		for lang, total := range totals {
+			grouped.AddTotal(p.Bucket(month), lang, total)
+		}
+	}
+	return grouped
+}
+
+// Buckets are the buckets of s in order.
+func (s Series) Buckets() []string {
+	buckets := make([]string, 0, len(s))
+	for bucket := range s {
+		buckets = append(buckets, bucket)
+	}
+	sort.Strings(buckets)
+	return buckets
+}
+
+// Shares are the byte shares of the languages in one bucket.
+func (s Series) Shares(bucket string) map[string]float64 {
+	total := int64(0)
+	for _, t := range s[bucket] {
+		total += t.Bytes
+	}
+	shares := make(map[string]float64, len(s[bucket]))
+	if total == 0 {
+		return shares
+	}
+	for lang, t := range s[bucket] {
+		shares[lang] = float64(t.Bytes) / float64(total)
+	}
+	return shares
+}
+
+// Mover is the change in byte share of a language between two buckets.
+type Mover struct {
+	Language string  `json:"language"`
+	From     float64 `json:"from"`
+	To       float64 `json:"to"`
+}
+
+func (m Mover) Change() float64 {
+	return m.To - m.From
+}
+
+// Movers are the n languages whose share changed the most between the
+// buckets from and to, gainers and losers alike.
+func (s Series) Movers(from, to string, n int) []Mover {
+	before, after := s.Shares(from), s.Shares(to)
+	var movers []Mover
+	for lang, share := range after {
+		movers = append(movers, Mover{Language: lang, From: before[lang], To: share})
+	}
+	for lang, share := range before {
+		if _, ok := after[lang]; !ok {
+			movers = append(movers, Mover{Language: lang, From: share})
+		}
+	}
+	sort.Slice(movers, func(i, j int) bool {
+		a, b := math.Abs(movers[i].Change()), math.Abs(movers[j].Change())
+		if a != b {
+			return a > b
+		}
+		return movers[i].Language < movers[j].Language
+	})
+	if n > 0 && len(movers) > n {
+		movers = movers[:n]
+	}
+	return movers
+}
@@ -0,0 +1,59 @@
+package stats
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPeriod_Bucket_names_months_quarters_and_years(t *testing.T) {
+	assert.Equal(t, "2018-08", Month.Bucket("2018-08"))
+	assert.Equal(t, "2018-Q3", Quarter.Bucket("2018-08"))
+	assert.Equal(t, "2018-Q4", Quarter.Bucket("2018-12"))
+	assert.Equal(t, "2018", Year.Bucket("2018-08"))
+}
+
+func TestSeries_Add_buckets_by_month_and_takes_back(t *testing.T) {
+	series := make(Series)
+	series.Add(time.Date(2018, 1, 31, 23, 0, 0, 0, time.UTC), map[string]int{"Go": 10, "C": 0}, 1)
+	series.Add(time.Date(2018, 2, 1, 0, 0, 0, 0, time.UTC), map[string]int{"Go": 5}, 1)
+	series.Add(time.Time{}, map[string]int{"Go": 99}, 1)
+	series.Add(time.Date(2018, 2, 1, 0, 0, 0, 0, time.UTC), map[string]int{"Go": 5}, -1)
+
+	assert.Equal(t, Series{"2018-01": {"Go": {Bytes: 10, Repos: 1}}}, series)
+}
+
+func TestSeries_By_sums_months_into_wider_buckets(t *testing.T) {
+	series := Series{
+		"2018-01": {"Go": {Bytes: 10, Repos: 1}},
+		"2018-04": {"Go": {Bytes: 5, Repos: 1}, "C": {Bytes: 5, Repos: 1}},
+		"2019-01": {"Rust": {Bytes: 1, Repos: 1}},
+	}
+
+	years := series.By(Year)
+
+	assert.Equal(t, []string{"2018", "2019"}, years.Buckets())
+	assert.Equal(t, Total{Bytes: 15, Repos: 2}, years["2018"]["Go"])
+	assert.Equal(t, map[string]float64{"Go": 0.75, "C": 0.25}, years.Shares("2018"))
+}
+
+func TestSeries_Movers_ranks_gainers_and_losers_by_change(t *testing.T) {
+	series := Series{
+		"2018": {"Go": {Bytes: 50}, "Perl": {Bytes: 40}, "C": {Bytes: 10}},
+		"2024": {"Go": {Bytes: 55}, "Rust": {Bytes: 35}, "C": {Bytes: 10}},
+	}
+
+	movers := series.Movers("2018", "2024", 2)
+
+	assert.Equal(t, []Mover{{Language: "Perl", From: 0.4, To: 0}, {Language: "Rust", From: 0, To: 0.35}}, movers)
+}
+
+func TestParsePeriod_rejects_unknown_periods(t *testing.T) {
+	p, err := ParsePeriod("Quarter")
+	assert.Nil(t, err)
+	assert.Equal(t, Quarter, p)
+
+	_, err = ParsePeriod("week")
+	assert.NotNil(t, err)
+}
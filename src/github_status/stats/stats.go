@@ -0,0 +1,173 @@
+// Package stats describes how languages are distributed over repositories:
+// shares of bytes and of repos, the spread of each language's size per repo
+// and how far the shares can be trusted.
+package stats
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// Mean of values, 0 for none.
+func Mean(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sum := 0.0
+	for _, value := range values {
+		sum += value
+	}
+	return sum / float64(len(values))
+}
+
+// Percentile of sorted values, interpolating linearly between the closest
+// ranks. p is between 0 and 100.
+func Percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := p / 100 * float64(len(sorted)-1)
+	if rank <= 0 {
+		return sorted[0]
+	}
+	if rank >= float64(len(sorted)-1) {
+		return sorted[len(sorted)-1]
+	}
+	lower := int(rank)
+	fraction := rank - float64(lower)
+	return sorted[lower] + fraction*(sorted[lower+1]-sorted[lower])
+}
+
+// Bucket counts the values from Min up to, but not including, Max.
+type Bucket struct {
+	Min   int64 `json:"min"`
+	Max   int64 `json:"max"`
+	Count int   `json:"count"`
+}
+
+// logBucket is the power of two bucket of value: 0 holds [1, 2), 1 holds
+// [2, 4) and so on. Values below 1 go to bucket -1.
+func logBucket(value int64) int {
+	if value < 1 {
+		return -1
+	}
+	bucket := 0
+	for value > 1 {
+		value >>= 1
+		bucket++
+	}
+	return bucket
+}
+
+func bucketBounds(bucket int) (int64, int64) {
+	if bucket < 0 {
+		return 0, 1
+	}
+	return int64(1) << uint(bucket), int64(1) << uint(bucket+1)
+}
+
+// LogHistogram counts values in power of two buckets, from the smallest to
+// the largest bucket that holds any.
+func LogHistogram(values []int64) []Bucket {
+	counts := make(map[int]int)
+	for _, value := range values {
+		counts[logBucket(value)]++
+	}
+	return histogram(counts)
+}
+
+func histogram(counts map[int]int) []Bucket {
+	if len(counts) == 0 {
+		return nil
+	}
+	first, last := math.MaxInt32, math.MinInt32
+	for bucket, count := range counts {
+		if count == 0 {
+			continue
+		}
+		if bucket < first {
+			first = bucket
+		}
+		if bucket > last {
+			last = bucket
+		}
+	}
+	var buckets []Bucket
+	for bucket := first; bucket <= last; bucket++ {
+		min, max := bucketBounds(bucket)
+		buckets = append(buckets, Bucket{Min: min, Max: max, Count: counts[bucket]})
+	}
+	return buckets
+}
+
+// Interval is a confidence interval.
+type Interval struct {
+	Low  float64 `json:"low"`
+	High float64 `json:"high"`
+}
+
+// Bootstrap estimates confidence intervals at level (such as 0.95) for k
+// statistics of n observations by recomputing them over resamples drawn with
+// replacement. statistics gets the indexes of one resample and sets the k
+// statistics in values, which start at 0.
+func Bootstrap(r *rand.Rand, n int, k int, resamples int, level float64, statistics func(indexes []int, values []float64)) []Interval {
+	if n == 0 || resamples == 0 {
+		return nil
+	}
+	estimates := make([][]float64, k)
+	for s := range estimates {
+		estimates[s] = make([]float64, resamples)
+	}
+	indexes := make([]int, n)
+	values := make([]float64, k)
+	for i := 0; i < resamples; i++ {
+		for j := range indexes {
+			indexes[j] = r.Intn(n)
+		}
+		for s := range values {
+			values[s] = 0
+		}
+		statistics(indexes, values)
+		for s, value := range values {
+			estimates[s][i] = value
+		}
+	}
+
+	tail := (1 - level) / 2 * 100
+	intervals := make([]Interval, k)
+	for s, values := range estimates {
+		sort.Float64s(values)
+		intervals[s] = Interval{Low: Percentile(values, tail), High: Percentile(values, 100-tail)}
+	}
+	return intervals
+}
+
+// Z95 is the standard score of a two-sided 95% confidence interval.
+const Z95 = 1.96
+
+// Ratio estimates sum(ys)/sum(xs) for pairs of observations, such as repos
+// found per IDs covered by each sampled page, with its standard error. The
+// error is 0 until there are two pairs.
+func Ratio(ys, xs []float64) (ratio float64, stderr float64) {
+	sumY, sumX := 0.0, 0.0
+	for i := range xs {
+		sumY += ys[i]
+		sumX += xs[i]
+	}
+	if sumX == 0 {
+		return 0, 0
+	}
+	ratio = sumY / sumX
+	n := float64(len(xs))
+	if n < 2 {
+		return ratio, 0
+	}
+
+	squares := 0.0
+	for i := range xs {
+		residual := ys[i] - ratio*xs[i]
+		squares += residual * residual
+	}
+	return ratio, math.Sqrt(squares/(n*(n-1))) / (sumX / n)
+}
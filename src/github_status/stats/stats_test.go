@@ -0,0 +1,68 @@
+package stats
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMean_of_nothing_is_zero(t *testing.T) {
+	assert.Equal(t, 0.0, Mean(nil))
+	assert.Equal(t, 2.0, Mean([]float64{1, 2, 3}))
+}
+
+func TestPercentile_interpolates_between_ranks(t *testing.T) {
+	sorted := []float64{10, 20, 30, 40}
+
+	assert.Equal(t, 10.0, Percentile(sorted, 0))
+	assert.Equal(t, 25.0, Percentile(sorted, 50))
+	assert.Equal(t, 40.0, Percentile(sorted, 100))
+	assert.InDelta(t, 37.0, Percentile(sorted, 90), 1e-9)
+	assert.Equal(t, 0.0, Percentile(nil, 50))
+}
+
+func TestLogHistogram_counts_powers_of_two_without_gaps(t *testing.T) {
+	buckets := LogHistogram([]int64{1, 3, 3, 9})
+
+	assert.Equal(t, []Bucket{
+		{Min: 1, Max: 2, Count: 1},
+		{Min: 2, Max: 4, Count: 2},
+		{Min: 4, Max: 8, Count: 0},
+		{Min: 8, Max: 16, Count: 1},
+	}, buckets)
+	assert.Nil(t, LogHistogram(nil))
+}
+
+func TestBootstrap_brackets_the_statistic(t *testing.T) {
+	values := make([]float64, 1000)
+	for i := range values {
+		values[i] = float64(i % 10)
+	}
+
+	intervals := Bootstrap(rand.New(rand.NewSource(1)), len(values), 1, 200, 0.95, func(indexes []int, mean []float64) {
+		for _, i := range indexes {
+			mean[0] += values[i]
+		}
+		mean[0] /= float64(len(indexes))
+	})
+
+	assert.Len(t, intervals, 1)
+	assert.True(t, intervals[0].Low < 4.5)
+	assert.True(t, intervals[0].High > 4.5)
+	assert.True(t, intervals[0].High-intervals[0].Low < 0.5)
+}
+
+func TestRatio_estimates_the_ratio_of_totals_with_its_error(t *testing.T) {
+	ratio, stderr := Ratio([]float64{10, 20, 30}, []float64{100, 200, 300})
+	assert.InDelta(t, 0.1, ratio, 1e-9)
+	assert.InDelta(t, 0, stderr, 1e-9)
+
+	ratio, stderr = Ratio([]float64{5, 15}, []float64{100, 100})
+	assert.InDelta(t, 0.1, ratio, 1e-9)
+	assert.InDelta(t, 0.05, stderr, 1e-9)
+
+	ratio, stderr = Ratio([]float64{5}, []float64{100})
+	assert.InDelta(t, 0.05, ratio, 1e-9)
+	assert.Equal(t, 0.0, stderr)
+}
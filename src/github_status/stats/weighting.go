@@ -0,0 +1,83 @@
+package stats
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Weighting is how much each language of a repo counts towards the shares.
+// Raw bytes let one huge repo outweigh thousands of small ones; the other
+// schemes bound what a single repo contributes.
+type Weighting string
+
+const (
+	// Raw counts every byte.
+	Raw Weighting = "raw"
+	// Log counts log2(1+bytes) per language, so size matters less and less.
+	Log Weighting = "log"
+	// Vote gives every repo one vote split by its byte shares.
+	Vote Weighting = "vote"
+	// Primary gives every repo one vote for its largest language.
+	Primary Weighting = "primary"
+	// Capped counts bytes but scales a repo down to at most the cap.
+	Capped Weighting = "capped"
+)
+
+var Weightings = []Weighting{Raw, Log, Vote, Primary, Capped}
+
+// DefaultCap is the most bytes one repo counts for under Capped.
+const DefaultCap = 1 << 20
+
+func ParseWeighting(name string) (Weighting, error) {
+	w := Weighting(strings.ToLower(strings.TrimSpace(name)))
+	for _, known := range Weightings {
+		if w == known {
+			return w, nil
+		}
+	}
+	return "", fmt.Errorf("unknown weighting %q, want one of raw, log, vote, primary, capped", name)
+}
+
+// Weigh returns the weight of each language of one repo. cap only matters to
+// Capped.
+func Weigh(w Weighting, languages map[string]int, cap int) map[string]float64 {
+	total := 0
+	primary := ""
+	for lang, bytes := range languages {
+		if bytes <= 0 {
+			continue
+		}
+		total += bytes
+		if primary == "" || bytes > languages[primary] || bytes == languages[primary] && lang < primary {
+			primary = lang
+		}
+	}
+	if total == 0 {
+		return nil
+	}
+
+	if w == Primary {
+		return map[string]float64{primary: 1}
+	}
+	scale := 1.0
+	switch {
+	case w == Vote:
+		scale = 1 / float64(total)
+	case w == Capped && cap > 0 && total > cap:
+		scale = float64(cap) / float64(total)
+	}
+
+	weights := make(map[string]float64, len(languages))
+	for lang, bytes := range languages {
+		if bytes <= 0 {
+			continue
+		}
+		if w == Log {
+			weights[lang] = math.Log2(1 + float64(bytes))
+		} else {
+			weights[lang] = float64(bytes) * scale
+		}
+	}
+	return weights
+}
@@ -0,0 +1,32 @@
+package stats
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWeigh_bounds_what_one_repo_counts_for(t *testing.T) {
+	languages := map[string]int{"C": 3000, "Go": 1000, "Empty": 0}
+
+	assert.Equal(t, map[string]float64{"C": 3000, "Go": 1000}, Weigh(Raw, languages, 0))
+	assert.Equal(t, map[string]float64{"C": 0.75, "Go": 0.25}, Weigh(Vote, languages, 0))
+	assert.Equal(t, map[string]float64{"C": 1}, Weigh(Primary, languages, 0))
+	assert.Equal(t, map[string]float64{"C": 300, "Go": 100}, Weigh(Capped, languages, 400))
+	assert.Equal(t, map[string]float64{"C": 3000, "Go": 1000}, Weigh(Capped, languages, 10000))
+	assert.InDelta(t, 9.967, Weigh(Log, languages, 0)["Go"], 0.001)
+}
+
+func TestWeigh_breaks_primary_ties_by_name(t *testing.T) {
+	assert.Equal(t, map[string]float64{"C": 1}, Weigh(Primary, map[string]int{"Go": 5, "C": 5}, 0))
+	assert.Nil(t, Weigh(Primary, map[string]int{"Go": 0}, 0))
+}
+
+func TestParseWeighting_rejects_unknown_names(t *testing.T) {
+	w, err := ParseWeighting(" Vote ")
+	assert.Nil(t, err)
+	assert.Equal(t, Vote, w)
+
+	_, err = ParseWeighting("median")
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,103 @@
+package storage
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github_status/stats"
+	"labix.org/v2/mgo"
+)
+
+// Checkpoint records how far a crawl of /repositories got. Since is the id
+// cursor of the next page to fetch.
+type Checkpoint struct {
+	Since     int64          `json:"since" bson:"since"`
+	Processed int            `json:"processed" bson:"processed"`
+	UpdatedAt time.Time      `json:"updated_at" bson:"updated_at"`
+	Languages map[string]int `json:"languages,omitempty" bson:"-"`
+	// Series are the languages by month of each date, see CountSeries.
+	Series map[string]stats.Series `json:"series,omitempty" bson:"-"`
+	// Filters are the flags that filtered the repos of the crawl, Types and
+	// Group how it counted their languages, so an export of the checkpoint
+	// can tell.
+	Filters []string `json:"filters,omitempty" bson:"filters,omitempty"`
+	Types   string   `json:"types,omitempty" bson:"types,omitempty"`
+	Group   bool     `json:"group,omitempty" bson:"group,omitempty"`
+}
+
+type Checkpointer interface {
+	Load() (Checkpoint, bool, error)
+	Save(Checkpoint) error
+}
+
+type FileCheckpointer struct {
+	Path string
+}
+
+func (f FileCheckpointer) Load() (Checkpoint, bool, error) {
+	var checkpoint Checkpoint
+	data, err := ioutil.ReadFile(f.Path)
+	if os.IsNotExist(err) {
+		return checkpoint, false, nil
+	}
+	if err != nil {
+		return checkpoint, false, err
+	}
+	err = json.Unmarshal(data, &checkpoint)
+	return checkpoint, err == nil, err
+}
+
+// Save writes to a temporary file and renames it over the old checkpoint so a
+// crash never leaves a half written file behind.
+func (f FileCheckpointer) Save(checkpoint Checkpoint) error {
+	data, err := json.Marshal(checkpoint)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(f.Path), filepath.Base(f.Path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), f.Path)
+}
+
+const checkpointId = "repositories"
+
+type mongoCheckpointer struct {
+	collection *mgo.Collection
+}
+
+func (s *Store) Checkpoints() Checkpointer {
+	return mongoCheckpointer{s.session.DB(s.database).C("checkpoints")}
+}
+
+func (m mongoCheckpointer) Load() (Checkpoint, bool, error) {
+	var checkpoint Checkpoint
+	err := m.collection.FindId(checkpointId).One(&checkpoint)
+	if err == mgo.ErrNotFound {
+		return checkpoint, false, nil
+	}
+	return checkpoint, err == nil, err
+}
+
+func (m mongoCheckpointer) Save(checkpoint Checkpoint) error {
+	_, err := m.collection.UpsertId(checkpointId, checkpoint)
+	return err
+}
@@ -0,0 +1,42 @@
+package storage
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github_status/stats"
+)
+
+func TestFileCheckpointer_Load_reports_a_missing_checkpoint(t *testing.T) {
+	checkpointer := FileCheckpointer{Path: filepath.Join(t.TempDir(), "checkpoint.json")}
+
+	_, found, err := checkpointer.Load()
+
+	assert.Nil(t, err)
+	assert.False(t, found)
+}
+
+func TestFileCheckpointer_Save_round_trips_a_checkpoint(t *testing.T) {
+	dir := t.TempDir()
+	checkpointer := FileCheckpointer{Path: filepath.Join(dir, "checkpoint.json")}
+	checkpoint := Checkpoint{
+		Since:     369,
+		Processed: 100,
+		UpdatedAt: time.Unix(1385779257, 0).UTC(),
+		Languages: map[string]int{"Ruby": 1024},
+		Series:    map[string]stats.Series{Created: {"2013-11": {"Ruby": {Bytes: 1024, Repos: 1}}}},
+	}
+
+	assert.Nil(t, checkpointer.Save(checkpoint))
+	loaded, found, err := checkpointer.Load()
+
+	assert.Nil(t, err)
+	assert.True(t, found)
+	assert.Equal(t, checkpoint, loaded)
+
+	files, _ := ioutil.ReadDir(dir)
+	assert.Len(t, files, 1)
+}
@@ -0,0 +1,259 @@
+package storage
+
+import (
+	"strings"
+	"time"
+
+	"github_status/github"
+	"labix.org/v2/mgo"
+	"labix.org/v2/mgo/bson"
+)
+
+const DefaultDatabase = "github_stats"
+
+// ErrNotFound is returned for a repo that was never stored.
+var ErrNotFound = mgo.ErrNotFound
+
+type RepoLanguages struct {
+	ID        int64          `bson:"id,omitempty" json:"id,omitempty"`
+	FullName  string         `bson:"full_name" json:"full_name"`
+	Owner     string         `bson:"owner,omitempty" json:"owner,omitempty"`
+	OwnerType string         `bson:"owner_type,omitempty" json:"owner_type,omitempty"`
+	Fork      bool           `bson:"fork" json:"fork"`
+	Archived  bool           `bson:"archived" json:"archived"`
+	Stars     int            `bson:"stars" json:"stars"`
+	Size      int            `bson:"size" json:"size"`
+	License   string         `bson:"license,omitempty" json:"license,omitempty"`
+	Topics    []string       `bson:"topics,omitempty" json:"topics,omitempty"`
+	CreatedAt time.Time      `bson:"created_at,omitempty" json:"created_at"`
+	PushedAt  time.Time      `bson:"pushed_at,omitempty" json:"pushed_at"`
+	FetchedAt time.Time      `bson:"fetched_at" json:"fetched_at"`
+	Languages map[string]int `bson:"languages" json:"languages"`
+}
+
+func FromRepo(repo github.Repo, fetchedAt time.Time, languages map[string]int) RepoLanguages {
+	doc := RepoLanguages{
+		ID:        repo.ID,
+		FullName:  repo.FullName,
+		Owner:     repo.Owner.Login,
+		OwnerType: repo.Owner.Type,
+		Fork:      repo.Fork,
+		Archived:  repo.Archived,
+		Stars:     repo.StargazersCount,
+		Size:      repo.Size,
+		Topics:    repo.Topics,
+		CreatedAt: repo.CreatedAt,
+		PushedAt:  repo.PushedAt,
+		FetchedAt: fetchedAt,
+		Languages: languages,
+	}
+	if repo.License != nil {
+		doc.License = repo.License.SPDXID
+	}
+	return doc
+}
+
+// ToRepo gives back the attributes FromRepo kept, enough to filter the repo
+// again.
+func (r RepoLanguages) ToRepo() github.Repo {
+	repo := github.Repo{
+		ID:              r.ID,
+		FullName:        r.FullName,
+		Owner:           github.Owner{Login: r.Owner, Type: r.OwnerType},
+		Fork:            r.Fork,
+		Archived:        r.Archived,
+		StargazersCount: r.Stars,
+		Size:            r.Size,
+		Topics:          r.Topics,
+		CreatedAt:       r.CreatedAt,
+		PushedAt:        r.PushedAt,
+	}
+	if r.License != "" {
+		repo.License = &github.License{SPDXID: r.License}
+	}
+	return repo
+}
+
+type LanguageTotal struct {
+	Name  string `bson:"_id"`
+	Bytes int64  `bson:"bytes"`
+	Repos int    `bson:"repos"`
+}
+
+type Store struct {
+	session  *mgo.Session
+	database string
+}
+
+func Dial(url string, database string) (*Store, error) {
+	session, err := mgo.Dial(url)
+	if err != nil {
+		return nil, err
+	}
+	session.SetSafe(&mgo.Safe{})
+
+	if database == "" {
+		database = DefaultDatabase
+	}
+	store := &Store{session: session, database: database}
+	if err := store.EnsureIndexes(); err != nil {
+		session.Close()
+		return nil, err
+	}
+	return store, nil
+}
+
+func (s *Store) Close() {
+	s.session.Close()
+}
+
+func (s *Store) repos() *mgo.Collection {
+	return s.session.DB(s.database).C("repos")
+}
+
+func (s *Store) languages() *mgo.Collection {
+	return s.session.DB(s.database).C("languages")
+}
+
+func (s *Store) EnsureIndexes() error {
+	indexes := []struct {
+		collection *mgo.Collection
+		index      mgo.Index
+	}{
+		{s.repos(), mgo.Index{Key: []string{"full_name"}, Unique: true}},
+		{s.repos(), mgo.Index{Key: []string{"-fetched_at"}}},
+		{s.repos(), mgo.Index{Key: []string{"owner"}}},
+		{s.repos(), mgo.Index{Key: []string{"created_at"}}},
+		{s.repos(), mgo.Index{Key: []string{"-stars"}}},
+		{s.languages(), mgo.Index{Key: []string{"-bytes"}}},
+		{s.series(), mgo.Index{Key: []string{"_id.date"}}},
+	}
+	for _, i := range indexes {
+		if err := i.collection.EnsureIndex(i.index); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SaveRepo upserts the repo document and applies only the difference from
+// any previously stored version to the totals, so saving the same repo twice
+// (for instance when a resumed crawl repeats a page) never double counts. It
+// returns the version it replaced, or nil for a new repo, so that callers can
+// take back what they counted for it before. The series by date are moved
+// along the same way.
+//
+// The totals are incremented before the repo document is written, so a crash
+// in between leaves the old version stored and a resumed crawl applies the
+// difference again: the change of that one repo is counted twice, where the
+// other order would lose it for good.
+func (s *Store) SaveRepo(repo RepoLanguages) (*RepoLanguages, error) {
+	previous, err := s.Repo(repo.FullName)
+	if err != nil && err != mgo.ErrNotFound {
+		return nil, err
+	}
+	found := err == nil
+
+	delta := languageDelta(previous.Languages, repo.Languages)
+	for name, bytes := range delta {
+		repos := 0
+		if previous.Languages[name] == 0 && repo.Languages[name] > 0 {
+			repos = 1
+		} else if previous.Languages[name] > 0 && repo.Languages[name] == 0 {
+			repos = -1
+		}
+		if _, err := s.languages().UpsertId(name, languageIncrement(bytes, repos)); err != nil {
+			return nil, err
+		}
+	}
+	replaced := &previous
+	if !found {
+		replaced = nil
+	}
+	if err := s.saveSeries(replaced, repo); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.repos().Upsert(bson.M{"full_name": repo.FullName}, repoUpdate(repo)); err != nil {
+		return nil, err
+	}
+	return replaced, nil
+}
+
+func (s *Store) Repo(fullName string) (RepoLanguages, error) {
+	var doc RepoLanguages
+	err := s.repos().Find(bson.M{"full_name": fullName}).One(&doc)
+	doc.Languages = unescapeKeys(doc.Languages)
+	return doc, err
+}
+
+// EachRepo calls fn with every stored repo by name, stopping at the first
+// error fn returns.
+func (s *Store) EachRepo(fn func(RepoLanguages) error) error {
+	iter := s.repos().Find(nil).Sort("full_name").Iter()
+	var doc RepoLanguages
+	for iter.Next(&doc) {
+		doc.Languages = unescapeKeys(doc.Languages)
+		if err := fn(doc); err != nil {
+			iter.Close()
+			return err
+		}
+		doc = RepoLanguages{}
+	}
+	return iter.Close()
+}
+
+func (s *Store) LanguageTotals() ([]LanguageTotal, error) {
+	var totals []LanguageTotal
+	err := s.languages().Find(nil).Sort("-bytes").All(&totals)
+	return totals, err
+}
+
+func repoUpdate(repo RepoLanguages) RepoLanguages {
+	repo.Languages = escapeKeys(repo.Languages)
+	return repo
+}
+
+func languageIncrement(bytes int, repos int) bson.M {
+	inc := bson.M{"bytes": bytes}
+	if repos != 0 {
+		inc["repos"] = repos
+	}
+	return bson.M{"$inc": inc}
+}
+
+func languageDelta(previous, current map[string]int) map[string]int {
+	delta := make(map[string]int)
+	for name, bytes := range current {
+		if d := bytes - previous[name]; d != 0 {
+			delta[name] = d
+		}
+	}
+	for name, bytes := range previous {
+		if _, ok := current[name]; !ok {
+			delta[name] = -bytes
+		}
+	}
+	return delta
+}
+
+// MongoDB does not allow "." or a leading "$" in field names, both of which
+// show up in language names like "ASP.NET".
+var keyEscaper = strings.NewReplacer("%", "%25", ".", "%2E", "$", "%24")
+var keyUnescaper = strings.NewReplacer("%25", "%", "%2E", ".", "%24", "$")
+
+func escapeKeys(languages map[string]int) map[string]int {
+	escaped := make(map[string]int, len(languages))
+	for name, bytes := range languages {
+		escaped[keyEscaper.Replace(name)] = bytes
+	}
+	return escaped
+}
+
+func unescapeKeys(languages map[string]int) map[string]int {
+	unescaped := make(map[string]int, len(languages))
+	for name, bytes := range languages {
+		unescaped[keyUnescaper.Replace(name)] = bytes
+	}
+	return unescaped
+}
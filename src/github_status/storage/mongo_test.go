@@ -0,0 +1,89 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github_status/github"
+	"labix.org/v2/mgo/bson"
+)
+
+func TestEscapeKeys_round_trips_names_mongo_cannot_store(t *testing.T) {
+	languages := map[string]int{"ASP.NET": 1, "$hell": 2, "100%": 3, "Go": 4}
+
+	escaped := escapeKeys(languages)
+
+	assert.Contains(t, escaped, "ASP%2ENET")
+	assert.Contains(t, escaped, "%24hell")
+	assert.Equal(t, languages, unescapeKeys(escaped))
+}
+
+func TestRepoUpdate_escapes_the_language_names(t *testing.T) {
+	fetched := time.Unix(1385779257, 0)
+	update := repoUpdate(RepoLanguages{FullName: "golang/go", FetchedAt: fetched, Languages: map[string]int{"ASP.NET": 10}})
+
+	assert.Equal(t, RepoLanguages{
+		FullName:  "golang/go",
+		FetchedAt: fetched,
+		Languages: map[string]int{"ASP%2ENET": 10},
+	}, update)
+}
+
+func TestFromRepo_copies_the_attributes_to_slice_by(t *testing.T) {
+	created := time.Unix(1296068472, 0)
+	repo := github.Repo{
+		ID:              1296269,
+		FullName:        "octocat/Hello-World",
+		Owner:           github.Owner{Login: "octocat", Type: "User"},
+		Archived:        true,
+		StargazersCount: 80,
+		Size:            108,
+		License:         &github.License{SPDXID: "MIT"},
+		CreatedAt:       created,
+	}
+
+	doc := FromRepo(repo, created, map[string]int{"C": 1})
+
+	assert.Equal(t, "octocat", doc.Owner)
+	assert.Equal(t, "User", doc.OwnerType)
+	assert.True(t, doc.Archived)
+	assert.Equal(t, 80, doc.Stars)
+	assert.Equal(t, "MIT", doc.License)
+	assert.Equal(t, created, doc.CreatedAt)
+	assert.Equal(t, map[string]int{"C": 1}, doc.Languages)
+}
+
+func TestToRepo_gives_back_what_FromRepo_kept(t *testing.T) {
+	created := time.Unix(1296068472, 0)
+	repo := github.Repo{
+		ID:              1296269,
+		FullName:        "octocat/Hello-World",
+		Owner:           github.Owner{Login: "octocat", Type: "User"},
+		Fork:            true,
+		StargazersCount: 80,
+		Size:            108,
+		License:         &github.License{SPDXID: "MIT"},
+		CreatedAt:       created,
+	}
+
+	assert.Equal(t, repo, FromRepo(repo, created, nil).ToRepo())
+}
+
+func TestLanguageIncrement_counts_bytes_and_new_repos(t *testing.T) {
+	assert.Equal(t, bson.M{"$inc": bson.M{"bytes": 42, "repos": 1}}, languageIncrement(42, 1))
+	assert.Equal(t, bson.M{"$inc": bson.M{"bytes": -2}}, languageIncrement(-2, 0))
+}
+
+func TestLanguageDelta_only_contains_changes(t *testing.T) {
+	previous := map[string]int{"Go": 10, "C": 5, "Shell": 1}
+	current := map[string]int{"Go": 10, "C": 7, "Ruby": 3}
+
+	assert.Equal(t, map[string]int{"C": 2, "Ruby": 3, "Shell": -1}, languageDelta(previous, current))
+}
+
+func TestLanguageDelta_of_a_new_repo_is_its_languages(t *testing.T) {
+	current := map[string]int{"Go": 10}
+
+	assert.Equal(t, current, languageDelta(nil, current))
+}
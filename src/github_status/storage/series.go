@@ -0,0 +1,88 @@
+package storage
+
+import (
+	"time"
+
+	"github_status/stats"
+	"labix.org/v2/mgo"
+	"labix.org/v2/mgo/bson"
+)
+
+// The dates of a repo its languages can be totalled by over time.
+const (
+	Created = "created"
+	Pushed  = "pushed"
+)
+
+var Dates = []string{Created, Pushed}
+
+// Date returns the created or pushed date of the repo.
+func (r RepoLanguages) Date(date string) time.Time {
+	if date == Pushed {
+		return r.PushedAt
+	}
+	return r.CreatedAt
+}
+
+// CountSeries counts repo in the month of each of its dates, or takes it back
+// with a sign of -1. series is keyed by date.
+func CountSeries(series map[string]stats.Series, repo RepoLanguages, sign int) {
+	for _, date := range Dates {
+		if series[date] == nil {
+			series[date] = make(stats.Series)
+		}
+		series[date].Add(repo.Date(date), repo.Languages, sign)
+	}
+}
+
+type seriesKey struct {
+	Date     string `bson:"date"`
+	Month    string `bson:"month"`
+	Language string `bson:"language"`
+}
+
+type seriesTotal struct {
+	Key   seriesKey `bson:"_id"`
+	Bytes int64     `bson:"bytes"`
+	Repos int       `bson:"repos"`
+}
+
+func (s *Store) series() *mgo.Collection {
+	return s.session.DB(s.database).C("series")
+}
+
+// saveSeries moves the languages of a repo from the months of its previous
+// version, if any, to those of the new one.
+func (s *Store) saveSeries(previous *RepoLanguages, repo RepoLanguages) error {
+	delta := make(map[string]stats.Series)
+	if previous != nil {
+		CountSeries(delta, *previous, -1)
+	}
+	CountSeries(delta, repo, 1)
+
+	for date, series := range delta {
+		for month, totals := range series {
+			for lang, total := range totals {
+				inc := bson.M{"$inc": bson.M{"bytes": total.Bytes, "repos": total.Repos}}
+				if _, err := s.series().UpsertId(seriesKey{Date: date, Month: month, Language: lang}, inc); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// LanguageSeries returns the monthly language totals by date, Created or
+// Pushed, of every repo stored.
+func (s *Store) LanguageSeries(date string) (stats.Series, error) {
+	var totals []seriesTotal
+	if err := s.series().Find(bson.M{"_id.date": date}).All(&totals); err != nil {
+		return nil, err
+	}
+	series := make(stats.Series)
+	for _, t := range totals {
+		series.AddTotal(t.Key.Month, t.Key.Language, stats.Total{Bytes: t.Bytes, Repos: t.Repos})
+	}
+	return series, nil
+}
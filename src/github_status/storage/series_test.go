@@ -0,0 +1,23 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github_status/stats"
+)
+
+func TestCountSeries_moves_a_repo_to_the_months_of_its_new_version(t *testing.T) {
+	created := time.Date(2018, 3, 5, 0, 0, 0, 0, time.UTC)
+	before := RepoLanguages{CreatedAt: created, PushedAt: created, Languages: map[string]int{"Rust": 10}}
+	after := RepoLanguages{CreatedAt: created, PushedAt: created.AddDate(1, 0, 0), Languages: map[string]int{"Rust": 30}}
+	series := make(map[string]stats.Series)
+
+	CountSeries(series, before, 1)
+	CountSeries(series, before, -1)
+	CountSeries(series, after, 1)
+
+	assert.Equal(t, stats.Series{"2018-03": {"Rust": {Bytes: 30, Repos: 1}}}, series[Created])
+	assert.Equal(t, stats.Series{"2019-03": {"Rust": {Bytes: 30, Repos: 1}}}, series[Pushed])
+}
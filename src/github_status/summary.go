@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github_status/crawler"
+	"github_status/stats"
+)
+
+// writeStatsTable writes the distributions of the top languages by bytes.
+func writeStatsTable(w io.Writer, summary stats.Summary, top int) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(tw, "Language\tBytes\t95%% CI\tRepos\tMean\tMedian\tP90\tP99\t\n")
+	for _, name := range languagesByBytes(summary, top) {
+		s := summary.Languages[name]
+		fmt.Fprintf(tw, "%s\t%.1f%%\t%.1f-%.1f%%\t%.1f%%\t%s\t%s\t%s\t%s\t\n",
+			name, s.ByteShare*100, s.ByteShareCI.Low*100, s.ByteShareCI.High*100, s.RepoShare*100,
+			formatBytes(s.Mean), formatBytes(s.Median), formatBytes(s.P90), formatBytes(s.P99))
+	}
+	fmt.Fprintf(tw, "%d repos, %d sampled\t\t\t\t\t\t\t\t\n", summary.Repos, summary.Sampled)
+	tw.Flush()
+}
+
+// writeSampleTable scales the repo shares of a sample up to all of GitHub.
+// Shares are of the repos counted, estimates of all repos including those
+// filtered out, so both are read against the sample as a whole.
+func writeSampleTable(w io.Writer, estimate crawler.Estimate, summary stats.Summary, top int) {
+	fmt.Fprintf(w, "About %s ± %s public repos, from %d repos on %d pages of IDs up to %d\n",
+		formatCount(estimate.Population), formatCount(estimate.Margin), estimate.Repos, estimate.Pages, estimate.MaxID)
+	if estimate.Repos == 0 {
+		return
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(tw, "Language\tRepos\t95%% CI\tEst. repos\t95%% CI\t\n")
+	scale := estimate.Population * float64(summary.Repos) / float64(estimate.Repos)
+	for _, name := range languagesByBytes(summary, top) {
+		s := summary.Languages[name]
+		fmt.Fprintf(tw, "%s\t%.1f%%\t%.1f-%.1f%%\t%s\t%s-%s\t\n",
+			name, s.RepoShare*100, s.RepoShareCI.Low*100, s.RepoShareCI.High*100,
+			formatCount(s.RepoShare*scale), formatCount(s.RepoShareCI.Low*scale), formatCount(s.RepoShareCI.High*scale))
+	}
+	tw.Flush()
+}
+
+// writeWeightingTable compares the top languages' shares of this crawl under
+// every weighting, ordered by raw bytes.
+func writeWeightingTable(w io.Writer, weighted map[stats.Weighting]map[string]float64, top int) {
+	totals := make(map[stats.Weighting]float64)
+	for weighting, weights := range weighted {
+		for _, weight := range weights {
+			totals[weighting] += weight
+		}
+	}
+
+	raw := weighted[stats.Raw]
+	names := make([]string, 0, len(raw))
+	for name := range raw {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if raw[names[i]] != raw[names[j]] {
+			return raw[names[i]] > raw[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	if top > 0 && len(names) > top {
+		names = names[:top]
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprint(tw, "Language\t")
+	for _, weighting := range stats.Weightings {
+		fmt.Fprintf(tw, "%s\t", weighting)
+	}
+	fmt.Fprintln(tw)
+	for _, name := range names {
+		fmt.Fprintf(tw, "%s\t", name)
+		for _, weighting := range stats.Weightings {
+			share := 0.0
+			if totals[weighting] > 0 {
+				share = weighted[weighting][name] / totals[weighting]
+			}
+			fmt.Fprintf(tw, "%.1f%%\t", share*100)
+		}
+		fmt.Fprintln(tw)
+	}
+	tw.Flush()
+}
+
+func languagesByBytes(summary stats.Summary, top int) []string {
+	names := make([]string, 0, len(summary.Languages))
+	for name := range summary.Languages {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		a, b := summary.Languages[names[i]], summary.Languages[names[j]]
+		if a.Bytes != b.Bytes {
+			return a.Bytes > b.Bytes
+		}
+		return names[i] < names[j]
+	})
+	if top > 0 && len(names) > top {
+		names = names[:top]
+	}
+	return names
+}
+
+// formatBytes shortens a byte count to 999B, 12.3K, 4.5M or 6.7G.
+func formatBytes(bytes float64) string {
+	for _, unit := range []string{"B", "K", "M", "G"} {
+		if bytes < 1000 || unit == "G" {
+			if unit == "B" {
+				return fmt.Sprintf("%.0f%s", bytes, unit)
+			}
+			return fmt.Sprintf("%.1f%s", bytes, unit)
+		}
+		bytes /= 1000
+	}
+	return ""
+}
+
+// formatCount shortens a count to 999, 12.3K, 4.5M or 6.7G.
+func formatCount(count float64) string {
+	return strings.TrimSuffix(formatBytes(count), "B")
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github_status/crawler"
+	"github_status/stats"
+)
+
+func TestWriteStatsTable_lists_the_top_languages_by_bytes(t *testing.T) {
+	summary := stats.Summary{Repos: 10, Sampled: 10, Languages: map[string]stats.LanguageStats{
+		"Go": {Bytes: 900, ByteShare: 0.9, RepoShare: 0.5, ByteShareCI: stats.Interval{Low: 0.85, High: 0.95}, Mean: 180, Median: 150, P90: 300, P99: 1500},
+		"C":  {Bytes: 100, ByteShare: 0.1, RepoShare: 0.2},
+	}}
+
+	var out bytes.Buffer
+	writeStatsTable(&out, summary, 1)
+	lines := strings.Split(strings.TrimRight(out.String(), "\n"), "\n")
+
+	assert.Len(t, lines, 3)
+	assert.Contains(t, lines[0], "Language")
+	assert.Equal(t, []string{"Go", "90.0%", "85.0-95.0%", "50.0%", "180B", "150B", "300B", "1.5K"}, strings.Fields(lines[1]))
+	assert.Contains(t, lines[2], "10 repos, 10 sampled")
+}
+
+func TestFormatBytes_shortens_large_counts(t *testing.T) {
+	assert.Equal(t, "999B", formatBytes(999))
+	assert.Equal(t, "12.3K", formatBytes(12300))
+	assert.Equal(t, "4.5M", formatBytes(4500000))
+	assert.Equal(t, "6700.0G", formatBytes(6.7e12))
+}
+
+func TestWriteSampleTable_scales_repo_shares_to_the_population(t *testing.T) {
+	estimate := crawler.Estimate{MaxID: 1000000, Pages: 10, Repos: 1000, Population: 500000, Margin: 20000}
+	summary := stats.Summary{Repos: 800, Languages: map[string]stats.LanguageStats{
+		"Go": {Bytes: 900, RepoShare: 0.5, RepoShareCI: stats.Interval{Low: 0.45, High: 0.55}},
+	}}
+
+	var out bytes.Buffer
+	writeSampleTable(&out, estimate, summary, 10)
+	lines := strings.Split(strings.TrimRight(out.String(), "\n"), "\n")
+
+	assert.Equal(t, "About 500.0K ± 20.0K public repos, from 1000 repos on 10 pages of IDs up to 1000000", lines[0])
+	assert.Equal(t, []string{"Go", "50.0%", "45.0-55.0%", "200.0K", "180.0K-220.0K"}, strings.Fields(lines[2]))
+}
+
+func TestWriteWeightingTable_compares_shares_under_every_weighting(t *testing.T) {
+	weighted := map[stats.Weighting]map[string]float64{
+		stats.Raw:  {"C": 900, "Go": 100},
+		stats.Vote: {"C": 1, "Go": 3},
+	}
+
+	var out bytes.Buffer
+	writeWeightingTable(&out, weighted, 10)
+	lines := strings.Split(strings.TrimRight(out.String(), "\n"), "\n")
+
+	assert.Equal(t, []string{"Language", "raw", "log", "vote", "primary", "capped"}, strings.Fields(lines[0]))
+	assert.Equal(t, []string{"C", "90.0%", "0.0%", "25.0%", "0.0%", "0.0%"}, strings.Fields(lines[1]))
+	assert.Equal(t, []string{"Go", "10.0%", "0.0%", "75.0%", "0.0%", "0.0%"}, strings.Fields(lines[2]))
+}
@@ -0,0 +1,25 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd
+// +build linux darwin freebsd netbsd openbsd
+
+package main
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+type winsize struct {
+	Rows, Cols, Xpixel, Ypixel uint16
+}
+
+// terminalSize asks the terminal on f for its size, which fails when f is
+// not a terminal.
+func terminalSize(f *os.File) (width, height int, ok bool) {
+	var size winsize
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), uintptr(syscall.TIOCGWINSZ), uintptr(unsafe.Pointer(&size)))
+	if errno != 0 || size.Cols == 0 {
+		return 0, 0, false
+	}
+	return int(size.Cols), int(size.Rows), true
+}